@@ -0,0 +1,527 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chandanbsd/gator/internal/database"
+	"github.com/chandanbsd/gator/internal/feed"
+	"github.com/chandanbsd/gator/internal/schedule"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultAggWorkers = 4
+	// defaultDisableAfter is how many fetches of a feed may fail in a row
+	// before it is disabled.
+	defaultDisableAfter = 10
+	// maxFetchInterval caps how far a quiet feed is backed off.
+	maxFetchInterval = 24 * time.Hour
+	// startIntervalFactor sets the interval a new feed starts at, this many
+	// times --interval, leaving room for a busy feed to speed up towards
+	// --interval, the floor.
+	startIntervalFactor = 4
+	// duePollInterval is how often agg looks for feeds that became due.
+	duePollInterval = time.Minute
+	// claimLease is how long a claimed feed is held back from other agg
+	// processes. It only matters if the process dies before the feed is
+	// marked fetched.
+	claimLease = 15 * time.Minute
+	// fetchHistoryRetention is how long the feed_fetches log is kept.
+	fetchHistoryRetention = 30 * 24 * time.Hour
+	// shutdownGrace is how long in-flight fetches may take to finish after
+	// agg is asked to stop.
+	shutdownGrace = 30 * time.Second
+)
+
+func aggHandler(s *state, cmd command) error {
+
+	duration := cmd.durationFlag("interval")
+	if len(cmd.Arguments) >= 1 {
+		var err error
+		duration, err = time.ParseDuration(cmd.Arguments[0])
+		if err != nil {
+			return invalidArgs("unable to parse duration %q", cmd.Arguments[0])
+		}
+	}
+	if duration <= 0 {
+		return invalidArgs("a positive interval is required, e.g. gator agg --interval 1m")
+	}
+
+	// The number of workers may also be given as the second argument, it
+	// then goes through the same checks as --workers.
+	if len(cmd.Arguments) == 2 {
+		err := cmd.Flags.Set("workers", cmd.Arguments[1])
+		if err != nil {
+			return invalidArgs("the number of workers must be a positive integer")
+		}
+	}
+
+	opts, err := parseFetchFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	newAggregator(s, duration, opts).runAndReport(s.ctx)
+	return nil
+}
+
+// fetchOptions are how agg and serve collect feeds.
+type fetchOptions struct {
+	client       *feed.Client
+	workers      int
+	disableAfter int
+}
+
+// parseFetchFlags checks the fetchFlags of cmd and returns the options
+// they set.
+func parseFetchFlags(cmd command) (fetchOptions, error) {
+	workers := cmd.intFlag("workers")
+	if workers < 1 {
+		return fetchOptions{}, invalidArgs("the number of workers must be a positive integer")
+	}
+
+	disableAfter := cmd.intFlag("disable-after")
+	if disableAfter < 0 {
+		return fetchOptions{}, invalidArgs("--disable-after must not be negative")
+	}
+
+	connectTimeout := cmd.durationFlag("connect-timeout")
+	readTimeout := cmd.durationFlag("read-timeout")
+	timeout := cmd.durationFlag("fetch-timeout")
+	if connectTimeout <= 0 || readTimeout <= 0 || timeout <= 0 {
+		return fetchOptions{}, invalidArgs("--connect-timeout, --read-timeout and --fetch-timeout must be positive")
+	}
+	maxSize := cmd.intFlag("max-feed-mb")
+	if maxSize < 1 {
+		return fetchOptions{}, invalidArgs("--max-feed-mb must be a positive integer")
+	}
+
+	client := feed.NewClient(feed.ClientOptions{
+		ConnectTimeout: connectTimeout,
+		ReadTimeout:    readTimeout,
+		Timeout:        timeout,
+		MaxBodySize:    int64(maxSize) << 20,
+	})
+	return fetchOptions{client: client, workers: workers, disableAfter: disableAfter}, nil
+}
+
+// newAggregator returns an aggregator fetching every feed with opts.client
+// at most once per interval with opts.workers workers. Feeds failing
+// opts.disableAfter times in a row are disabled, never if it is zero.
+func newAggregator(s *state, interval time.Duration, opts fetchOptions) *aggregator {
+	return &aggregator{
+		s:      s,
+		client: opts.client,
+		policy: schedule.Policy{
+			Default:     startIntervalFactor * interval,
+			Min:         interval,
+			Max:         max(maxFetchInterval, interval),
+			MaxFailures: opts.disableAfter,
+		},
+		interval:     interval,
+		workers:      opts.workers,
+		pollInterval: min(interval, duePollInterval),
+	}
+}
+
+// aggregator fetches due feeds with a pool of workers until its context is
+// cancelled.
+type aggregator struct {
+	s            *state
+	client       *feed.Client
+	policy       schedule.Policy
+	interval     time.Duration
+	workers      int
+	pollInterval time.Duration
+
+	feedsFetched atomic.Int64
+	feedsFailed  atomic.Int64
+	postsAdded   atomic.Int64
+}
+
+// runAndReport runs the aggregator like run and prints what it did.
+func (a *aggregator) runAndReport(ctx context.Context) {
+	fmt.Printf("Collecting feeds every %v or as their schedule allows with %d workers\n", a.interval, a.workers)
+
+	start := time.Now()
+	a.run(ctx)
+
+	fmt.Printf("Stopped after %v: fetched %d feeds, %d failed, added %d posts\n",
+		time.Since(start).Round(time.Second),
+		a.feedsFetched.Load(),
+		a.feedsFailed.Load(),
+		a.postsAdded.Load(),
+	)
+}
+
+// run polls for due feeds until ctx is cancelled. Fetches that are in flight
+// at that point run on a separate context and get shutdownGrace to finish
+// and record their posts before they are cancelled too.
+func (a *aggregator) run(ctx context.Context) {
+	workCtx, cancelWork := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelWork()
+
+	stopGrace := context.AfterFunc(ctx, func() {
+		fmt.Printf("Shutting down, waiting up to %v for in-flight fetches\n", shutdownGrace)
+		time.AfterFunc(shutdownGrace, cancelWork)
+	})
+	defer stopGrace()
+
+	ticker := time.NewTicker(a.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		a.scrapeFeeds(ctx, workCtx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scrapeFeeds fetches every feed whose next_fetch_at has passed using a pool
+// of workers. Feeds are claimed in batches with GetNextFeedsToFetch, which
+// skips rows locked by other agg processes and leases the claimed feeds, so
+// several of them can share a database without fetching a feed twice. No new
+// feeds are claimed once ctx is cancelled, and claimed feeds no worker took
+// are released; the workers use workCtx.
+func (a *aggregator) scrapeFeeds(ctx, workCtx context.Context) {
+	jobs := make(chan database.Feed)
+
+	var wg sync.WaitGroup
+	for range a.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for nextFeed := range jobs {
+				newItems, err := scrapeFeed(workCtx, a.s, a.client, a.policy, nextFeed)
+				if err != nil && workCtx.Err() != nil {
+					// Cut short by shutdown, the feed is fetched again
+					// on the next start.
+					a.releaseFeeds([]database.Feed{nextFeed}, time.Now())
+				}
+				if err != nil {
+					a.feedsFailed.Add(1)
+					fmt.Printf("Failed to scrape %s: %v\n", nextFeed.Url, err)
+					continue
+				}
+				a.feedsFetched.Add(1)
+				a.postsAdded.Add(int64(newItems))
+			}
+		}()
+	}
+
+	for ctx.Err() == nil {
+		now := time.Now()
+		feeds, err := a.s.db.GetNextFeedsToFetch(ctx, database.GetNextFeedsToFetchParams{
+			LeaseUntil: sql.NullTime{Time: now.Add(claimLease), Valid: true},
+			DueAt:      sql.NullTime{Time: now, Valid: true},
+			MaxFeeds:   int32(a.workers),
+		})
+		if err != nil {
+			if ctx.Err() == nil {
+				fmt.Println(dbError(err, "claiming feeds to fetch"))
+			}
+			break
+		}
+		if len(feeds) == 0 {
+			break
+		}
+
+		for i, nextFeed := range feeds {
+			select {
+			case jobs <- nextFeed:
+				continue
+			case <-ctx.Done():
+			}
+			a.releaseFeeds(feeds[i:], now)
+			break
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+}
+
+// releaseFeeds gives back the lease on feeds claimed but not fetched, so
+// they are due again at dueAt instead of when the lease runs out. It runs
+// on shutdown, when the contexts of the aggregator may be cancelled. Feeds
+// marked fetched meanwhile keep their new next_fetch_at.
+func (a *aggregator) releaseFeeds(feeds []database.Feed, dueAt time.Time) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownGrace)
+	defer cancel()
+
+	for _, nextFeed := range feeds {
+		err := a.s.db.ReleaseFeed(ctx, database.ReleaseFeedParams{
+			DueAt:      sql.NullTime{Time: dueAt, Valid: true},
+			ID:         nextFeed.ID,
+			LeaseUntil: nextFeed.NextFetchAt,
+		})
+		if err != nil {
+			fmt.Println(dbError(err, "releasing %s", nextFeed.Url))
+		}
+	}
+}
+
+// scrapeFeed fetches a single feed, stores its posts and schedules its
+// next fetch. It returns the number of posts added.
+func scrapeFeed(ctx context.Context, s *state, client *feed.Client, policy schedule.Policy, nextFeed database.Feed) (int, error) {
+	fmt.Printf("Fetching %s\n", nextFeed.Url)
+
+	start := time.Now()
+	rssFeed, err := client.Fetch(ctx, nextFeed.Url, feed.Validators{
+		ETag:         nextFeed.Etag.String,
+		LastModified: nextFeed.LastModified.String,
+	})
+	fetchLog := database.CreateFeedFetchParams{
+		ID:         uuid.New(),
+		FeedID:     nextFeed.ID,
+		StartedAt:  start,
+		DurationMs: int32(time.Since(start) / time.Millisecond),
+	}
+	if err != nil {
+		// A fetch cut short by shutdown says nothing about the feed
+		if ctx.Err() != nil {
+			return 0, fmt.Errorf("%w: %w", errUpstreamFetch, err)
+		}
+		next := recordFailedFetch(ctx, s, policy, nextFeed, fetchLog, err)
+		return 0, fmt.Errorf("%w: %w, %s", errUpstreamFetch, err, next)
+	}
+	fetchLog.StatusCode = sql.NullInt32{Int32: int32(rssFeed.StatusCode), Valid: true}
+	fetchLog.Bytes = sql.NullInt64{Int64: rssFeed.Size, Valid: true}
+	fetchLog.ItemsParsed = int32(len(rssFeed.Items))
+
+	if rssFeed.NotModified {
+		fmt.Printf("%s has not changed since the last fetch\n", nextFeed.Url)
+	}
+
+	for _, item := range rssFeed.Items {
+		if item.PublishedEstimated {
+			fmt.Printf("Unrecognized publish date %q for %s, using fetch time\n", item.PubDate, item.Link)
+		}
+	}
+
+	counts, interval, err := ingestFeed(ctx, s, policy, nextFeed, rssFeed, fetchLog)
+	if err != nil {
+		err = dbError(err, "storing posts of %s", nextFeed.Url)
+		// The fetch went into the rolled back transaction, log it again
+		// as failed so the feed shows up in gator feed health.
+		if ctx.Err() != nil {
+			return 0, err
+		}
+		next := recordFailedFetch(ctx, s, policy, nextFeed, fetchLog, err)
+		return 0, fmt.Errorf("%w, %s", err, next)
+	}
+
+	fmt.Printf("Fetched %s: %d new, %d updated and %d unchanged of %d items, next fetch in %v\n",
+		nextFeed.Url, counts.inserted, counts.updated, counts.skipped, len(rssFeed.Items), interval)
+
+	return counts.inserted, nil
+}
+
+// ingestCounts tell what happened to the items of a fetched feed. Skipped
+// items were stored before and have not changed, or repeat an item of the
+// same fetch. Posts with an empty content hash, whose content was not known
+// when hashes were added, are refreshed but not counted as updated.
+type ingestCounts struct {
+	inserted int
+	updated  int
+	skipped  int
+}
+
+// ingestFeed stores the items of rssFeed as posts of nextFeed and records
+// the fetch, in fetchLog and on the feed, in one transaction, so a feed is
+// only marked fetched when its posts are in. The items go in with a single
+// statement whatever their number. It returns the interval until the next
+// fetch.
+func ingestFeed(ctx context.Context, s *state, policy schedule.Policy, nextFeed database.Feed, rssFeed *feed.Feed, fetchLog database.CreateFeedFetchParams) (ingestCounts, time.Duration, error) {
+	now := time.Now()
+
+	posts := database.UpsertPostsParams{
+		CreatedAt: now,
+		FeedID:    nextFeed.ID,
+	}
+	adopt := database.AdoptPostGUIDsParams{
+		FeedID: nextFeed.ID,
+	}
+
+	// A statement cannot upsert the same row twice, so repeated items are
+	// left out.
+	seen := make(map[string]bool, len(rssFeed.Items))
+	for _, item := range rssFeed.Items {
+		key := item.Key()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		guid := strings.TrimSpace(item.ID)
+		posts.Ids = append(posts.Ids, uuid.New())
+		posts.Titles = append(posts.Titles, item.Title)
+		posts.Urls = append(posts.Urls, item.Link)
+		posts.Descriptions = append(posts.Descriptions, item.Description)
+		posts.PublishedAts = append(posts.PublishedAts, item.Published)
+		posts.Guids = append(posts.Guids, guid)
+		posts.DedupKeys = append(posts.DedupKeys, key)
+		posts.ContentHashes = append(posts.ContentHashes, item.ContentHash())
+
+		// Posts stored before guids were recorded are known by their url.
+		if guid != "" {
+			adopt.Guids = append(adopt.Guids, guid)
+			adopt.DedupKeys = append(adopt.DedupKeys, key)
+			adopt.Urls = append(adopt.Urls, item.Link)
+		}
+	}
+
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return ingestCounts{}, 0, err
+	}
+	defer tx.Rollback()
+	qtx := s.db.WithTx(tx)
+
+	var counts ingestCounts
+	if len(adopt.Guids) > 0 {
+		err = qtx.AdoptPostGUIDs(ctx, adopt)
+		if err != nil {
+			return ingestCounts{}, 0, err
+		}
+	}
+	if len(posts.Ids) > 0 {
+		rows, err := qtx.UpsertPosts(ctx, posts)
+		if err != nil {
+			return ingestCounts{}, 0, err
+		}
+		for _, row := range rows {
+			switch {
+			case row.Inserted:
+				counts.inserted++
+			case row.Changed:
+				counts.updated++
+			}
+		}
+	}
+	counts.skipped = len(rssFeed.Items) - counts.inserted - counts.updated
+
+	interval := policy.Next(
+		time.Duration(nextFeed.FetchIntervalSeconds.Int32)*time.Second,
+		counts.inserted,
+		rssFeed.UpdateInterval,
+	)
+
+	markFeedFetchedParams := database.MarkFeedFetchedParams{
+		LastFetchedAt: sql.NullTime{
+			Time:  now,
+			Valid: true,
+		},
+		Etag: sql.NullString{
+			String: rssFeed.Validators.ETag,
+			Valid:  rssFeed.Validators.ETag != "",
+		},
+		LastModified: sql.NullString{
+			String: rssFeed.Validators.LastModified,
+			Valid:  rssFeed.Validators.LastModified != "",
+		},
+		NextFetchAt: sql.NullTime{
+			Time:  now.Add(interval),
+			Valid: true,
+		},
+		FetchIntervalSeconds: sql.NullInt32{
+			Int32: int32(interval / time.Second),
+			Valid: true,
+		},
+		SiteUrl: nextFeed.SiteUrl,
+		ID:      nextFeed.ID,
+	}
+	if rssFeed.Link != "" {
+		markFeedFetchedParams.SiteUrl = sql.NullString{String: rssFeed.Link, Valid: true}
+	}
+
+	err = qtx.MarkFeedFetched(ctx, markFeedFetchedParams)
+	if err != nil {
+		return ingestCounts{}, 0, err
+	}
+
+	fetchLog.ItemsInserted = int32(counts.inserted)
+	err = logFetch(ctx, qtx, fetchLog)
+	if err != nil {
+		return ingestCounts{}, 0, err
+	}
+
+	return counts, interval, tx.Commit()
+}
+
+// recordFailedFetch logs a fetch of nextFeed that failed with fetchErr and
+// counts it against the feed, which is retried after a growing backoff or,
+// once policy gives up on it, disabled. It returns what happens next to the
+// feed. Failing to record the fetch is reported but not returned, the fetch
+// error is what matters to the caller.
+func recordFailedFetch(ctx context.Context, s *state, policy schedule.Policy, nextFeed database.Feed, fetchLog database.CreateFeedFetchParams, fetchErr error) string {
+	var retryAfter time.Duration
+	var fetchError *feed.FetchError
+	if errors.As(fetchErr, &fetchError) {
+		fetchLog.StatusCode = sql.NullInt32{Int32: int32(fetchError.StatusCode), Valid: true}
+		fetchLog.Bytes = sql.NullInt64{Int64: fetchError.Size, Valid: true}
+		retryAfter = fetchError.RetryAfter
+	}
+	fetchLog.Error = sql.NullString{String: fetchErr.Error(), Valid: true}
+
+	now := time.Now()
+	failures := int(nextFeed.ConsecutiveFailures) + 1
+	retry := policy.Retry(failures, retryAfter)
+	failure := database.RecordFeedFailureParams{
+		NextFetchAt: sql.NullTime{Time: now.Add(retry), Valid: true},
+		ID:          nextFeed.ID,
+	}
+	next := fmt.Sprintf("retrying in %v", retry.Round(time.Second))
+	if policy.Disabled(failures) {
+		failure.DisabledAt = sql.NullTime{Time: now, Valid: true}
+		next = fmt.Sprintf("disabled after %d failed fetches in a row, gator feed enable %s turns it back on", failures, nextFeed.Url)
+	}
+
+	err := func() error {
+		tx, err := s.conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		qtx := s.db.WithTx(tx)
+
+		err = qtx.RecordFeedFailure(ctx, failure)
+		if err != nil {
+			return err
+		}
+		err = logFetch(ctx, qtx, fetchLog)
+		if err != nil {
+			return err
+		}
+		return tx.Commit()
+	}()
+	if err != nil {
+		fmt.Println(dbError(err, "recording failed fetch"))
+		return "retrying when its lease runs out"
+	}
+	return next
+}
+
+// logFetch adds fetchLog to the fetch history of its feed and drops the
+// entries older than fetchHistoryRetention.
+func logFetch(ctx context.Context, q *database.Queries, fetchLog database.CreateFeedFetchParams) error {
+	err := q.CreateFeedFetch(ctx, fetchLog)
+	if err != nil {
+		return err
+	}
+	return q.PruneFeedFetches(ctx, database.PruneFeedFetchesParams{
+		FeedID:    fetchLog.FeedID,
+		StartedAt: fetchLog.StartedAt.Add(-fetchHistoryRetention),
+	})
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chandanbsd/gator/internal/database"
+	"github.com/chandanbsd/gator/internal/output"
+	"github.com/chandanbsd/gator/internal/render"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultBrowseLimit = 2
+	// defaultPagerLimit is the page size of browse --pager unless --limit
+	// is given.
+	defaultPagerLimit = 10
+	// defaultDescriptionLength keeps browse output to a few lines per post.
+	defaultDescriptionLength = 300
+)
+
+func browseHandler(s *state, cmd command, u database.User) error {
+	limit32 := int32(cmd.intFlag("limit"))
+	if cmd.boolFlag("pager") && !cmd.isSet("limit") {
+		limit32 = defaultPagerLimit
+	}
+	if len(cmd.Arguments) == 1 {
+		limit64, err := strconv.ParseInt(cmd.Arguments[0], 10, 32)
+		if err != nil {
+			return invalidArgs("limit must be a number: %q", cmd.Arguments[0])
+		}
+		limit32 = int32(limit64)
+	}
+
+	if limit32 < 1 {
+		return invalidArgs("limit must be positive")
+	}
+
+	if cmd.boolFlag("unread") && cmd.boolFlag("all") {
+		return invalidArgs("give either --unread or --all, not both")
+	}
+
+	params := database.GetPostsForUserParams{
+		UserID:      u.ID,
+		IncludeRead: cmd.boolFlag("all"),
+		Limit:       limit32,
+	}
+	if feedURL := cmd.stringFlag("feed"); feedURL != "" {
+		params.FeedUrl = sql.NullString{String: feedURL, Valid: true}
+	}
+	if since := cmd.durationFlag("since"); since > 0 {
+		params.Since = sql.NullTime{Time: time.Now().Add(-since), Valid: true}
+	}
+
+	after, before := cmd.stringFlag("after"), cmd.stringFlag("before")
+	if after != "" && before != "" {
+		return invalidArgs("give either --after or --before, not both")
+	}
+	if after != "" {
+		cursor, err := parsePostCursor(after)
+		if err != nil {
+			return invalidArgs("%v", err)
+		}
+		params.AfterPublishedAt = sql.NullTime{Time: cursor.PublishedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	renderer := render.PostRenderer{
+		Terminal:       render.DetectTerminal(os.Stdout),
+		MaxDescription: cmd.intFlag("length"),
+		Now:            time.Now(),
+	}
+
+	if cmd.boolFlag("pager") {
+		if before != "" || cmd.Output != output.Table {
+			return invalidArgs("--pager pages forward in table output, it cannot be combined with --before or --output")
+		}
+		return pagePosts(s, params, renderer)
+	}
+
+	var posts []database.GetPostsForUserRow
+	var err error
+	if before != "" {
+		var cursor postCursor
+		cursor, err = parsePostCursor(before)
+		if err != nil {
+			return invalidArgs("%v", err)
+		}
+		posts, err = getNewerPosts(s, params, cursor)
+	} else {
+		posts, err = s.db.GetPostsForUser(s.ctx, params)
+	}
+	if err != nil {
+		return dbError(err, "getting posts for %s", u.Name)
+	}
+
+	// A page is full when there may be more posts past it. Going back with
+	// --before, the older side is known to have posts: the page it came from.
+	var previous, next string
+	if len(posts) > 0 {
+		if (before != "" && len(posts) == int(limit32)) || after != "" {
+			previous = cursorOf(posts[0]).String()
+		}
+		if before != "" || len(posts) == int(limit32) {
+			next = cursorOf(posts[len(posts)-1]).String()
+		}
+	}
+
+	if cmd.Output != output.Table {
+		records := make([]output.Record, 0, len(posts))
+		for _, post := range posts {
+			records = append(records, postRecord(post))
+		}
+		err = printRecords(cmd, postRecord(database.GetPostsForUserRow{}), records)
+		if err != nil {
+			return err
+		}
+		// stdout holds the records only, so the cursors go to stderr
+		printCursors(os.Stderr, previous, next)
+		return nil
+	}
+
+	if len(posts) == 0 {
+		fmt.Println("No posts to show")
+		return nil
+	}
+
+	err = renderPosts(os.Stdout, renderer, posts)
+	if err != nil {
+		return err
+	}
+	printCursors(os.Stdout, previous, next)
+	return nil
+}
+
+// getNewerPosts returns the page of posts just before cursor in browse
+// order, newest first like GetPostsForUser.
+func getNewerPosts(s *state, params database.GetPostsForUserParams, cursor postCursor) ([]database.GetPostsForUserRow, error) {
+	rows, err := s.db.GetNewerPostsForUser(s.ctx, database.GetNewerPostsForUserParams{
+		UserID:            params.UserID,
+		FeedUrl:           params.FeedUrl,
+		Since:             params.Since,
+		IncludeRead:       params.IncludeRead,
+		BeforePublishedAt: cursor.PublishedAt,
+		BeforeID:          cursor.ID,
+		Limit:             params.Limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	posts := make([]database.GetPostsForUserRow, 0, len(rows))
+	for _, row := range rows {
+		posts = append(posts, database.GetPostsForUserRow(row))
+	}
+	slices.Reverse(posts)
+	return posts, nil
+}
+
+// pagePosts shows one page of posts at a time, fetching the next one when
+// the user asks for it.
+func pagePosts(s *state, params database.GetPostsForUserParams, renderer render.PostRenderer) error {
+	input := bufio.NewReader(os.Stdin)
+	shown := 0
+
+	for {
+		posts, err := s.db.GetPostsForUser(s.ctx, params)
+		if err != nil {
+			return dbError(err, "getting posts")
+		}
+		shown += len(posts)
+
+		err = renderPosts(os.Stdout, renderer, posts)
+		if err != nil {
+			return err
+		}
+
+		if len(posts) < int(params.Limit) {
+			if shown == 0 {
+				fmt.Println("No posts to show")
+			} else {
+				fmt.Printf("End of posts, %d shown\n", shown)
+			}
+			return nil
+		}
+
+		cursor := cursorOf(posts[len(posts)-1])
+		params.AfterPublishedAt = sql.NullTime{Time: cursor.PublishedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+
+		fmt.Fprint(os.Stderr, "-- Enter for more, q to quit --")
+		answer, err := readLine(s.ctx, input)
+		fmt.Fprintln(os.Stderr)
+		if err != nil || strings.EqualFold(strings.TrimSpace(answer), "q") {
+			fmt.Printf("Continue with --after %s\n", cursor)
+			return nil
+		}
+	}
+}
+
+// readLine reads a line from r, giving up when ctx is cancelled so Ctrl-C
+// still ends the pager.
+func readLine(ctx context.Context, r *bufio.Reader) (string, error) {
+	type result struct {
+		line string
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		line, err := r.ReadString('\n')
+		done <- result{line, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.line, res.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func renderPosts(w io.Writer, renderer render.PostRenderer, posts []database.GetPostsForUserRow) error {
+	for _, post := range posts {
+		err := renderer.Render(w, render.Post{
+			ID:          shortID(post.ID),
+			FeedName:    post.FeedName,
+			Title:       post.Title,
+			URL:         post.Url,
+			Published:   post.PublishedAt,
+			Description: post.Description.String,
+			Read:        post.Read,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printCursors(w io.Writer, previous, next string) {
+	if previous != "" {
+		fmt.Fprintf(w, "Previous page: --before %s\n", previous)
+	}
+	if next != "" {
+		fmt.Fprintf(w, "Next page: --after %s\n", next)
+	}
+}
+
+// postCursor is a position in browse order, which is newest first with
+// the id breaking ties, so pages stay stable while posts are added.
+type postCursor struct {
+	PublishedAt time.Time
+	ID          uuid.UUID
+}
+
+func cursorOf(post database.GetPostsForUserRow) postCursor {
+	return postCursor{PublishedAt: post.PublishedAt, ID: post.ID}
+}
+
+// String encodes the cursor as an opaque token for --after and --before.
+// Postgres keeps microseconds, so nothing is lost.
+func (c postCursor) String() string {
+	raw := strconv.FormatInt(c.PublishedAt.UnixMicro(), 10) + "." + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func parsePostCursor(token string) (postCursor, error) {
+	invalid := fmt.Errorf("%q is not a cursor printed by browse", token)
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return postCursor{}, invalid
+	}
+	micros, id, ok := strings.Cut(string(raw), ".")
+	if !ok {
+		return postCursor{}, invalid
+	}
+
+	n, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return postCursor{}, invalid
+	}
+	postID, err := uuid.Parse(id)
+	if err != nil {
+		return postCursor{}, invalid
+	}
+
+	return postCursor{PublishedAt: time.UnixMicro(n), ID: postID}, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/lib/pq"
+)
+
+// Kinds of failure a command can end with. Handlers wrap one of these
+// together with the underlying error, and commands.run turns the kind into
+// the exit code so scripts can tell them apart.
+var (
+	errInvalidArgs   = errors.New("invalid arguments")
+	errNotFound      = errors.New("not found")
+	errAlreadyExists = errors.New("already exists")
+	errUpstreamFetch = errors.New("upstream fetch failed")
+	errDatabase      = errors.New("database error")
+)
+
+const (
+	exitFailure       = 1
+	exitInvalidArgs   = 2
+	exitNotFound      = 3
+	exitAlreadyExists = 4
+	exitUpstreamFetch = 5
+	exitDatabase      = 6
+)
+
+// uniqueViolation is the Postgres error code for a unique constraint
+// violation.
+const uniqueViolation = "23505"
+
+func exitCode(err error) int {
+	switch {
+	case errors.Is(err, errInvalidArgs):
+		return exitInvalidArgs
+	case errors.Is(err, errNotFound):
+		return exitNotFound
+	case errors.Is(err, errAlreadyExists):
+		return exitAlreadyExists
+	case errors.Is(err, errUpstreamFetch):
+		return exitUpstreamFetch
+	case errors.Is(err, errDatabase):
+		return exitDatabase
+	default:
+		return exitFailure
+	}
+}
+
+// httpStatus is the counterpart of exitCode for the API of gator serve.
+func httpStatus(err error) int {
+	switch {
+	case errors.Is(err, errInvalidArgs):
+		return http.StatusBadRequest
+	case errors.Is(err, errNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errAlreadyExists):
+		return http.StatusConflict
+	case errors.Is(err, errUpstreamFetch):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// invalidArgs returns an errInvalidArgs error with a message for the user.
+func invalidArgs(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errInvalidArgs, fmt.Sprintf(format, args...))
+}
+
+// dbError wraps an error returned by a database.Queries call. Missing rows
+// and unique violations get their own kinds, anything else is errDatabase.
+// The sql.ErrNoRows cause is dropped since the kind already says it all.
+func dbError(err error, format string, args ...any) error {
+	message := fmt.Sprintf(format, args...)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%s: %w", message, errNotFound)
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+		return fmt.Errorf("%s: %w: %w", message, errAlreadyExists, err)
+	}
+
+	return fmt.Errorf("%s: %w: %w", message, errDatabase, err)
+}
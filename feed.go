@@ -0,0 +1,135 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/chandanbsd/gator/internal/database"
+	"github.com/chandanbsd/gator/internal/output"
+)
+
+const (
+	// defaultStaleAfter is how long a feed may go without a successful
+	// fetch before feed health reports it, a few of the longest intervals
+	// the scheduler hands out.
+	defaultStaleAfter = 3 * maxFetchInterval
+	defaultSlowAfter  = 5 * time.Second
+	// healthRecentFetches is how many of the latest fetches of a feed its
+	// average duration is taken over.
+	healthRecentFetches = 10
+)
+
+// Health of a feed as reported by feed health, worst first.
+const (
+	healthDisabled = "disabled"
+	healthFailing  = "failing"
+	healthStale    = "stale"
+	healthSlow     = "slow"
+	healthOK       = "ok"
+)
+
+// feedHandler runs the feed subcommand named by the first argument.
+func feedHandler(s *state, cmd command) error {
+	subcommand := cmd.Arguments[0]
+	cmd.Arguments = cmd.Arguments[1:]
+
+	switch subcommand {
+	case "health":
+		if len(cmd.Arguments) > 0 {
+			return invalidArgs("usage: gator feed health [--stale 72h] [--slow 5s] [--all]")
+		}
+		return feedHealthHandler(s, cmd)
+	case "enable", "disable":
+		if len(cmd.Arguments) != 1 {
+			return invalidArgs("usage: gator feed %s <url>", subcommand)
+		}
+		return feedEnableHandler(s, cmd, subcommand == "enable")
+	default:
+		return invalidArgs("unknown feed subcommand %q, usage: gator feed health|enable|disable", subcommand)
+	}
+}
+
+// feedEnableHandler enables or disables fetching the feed at the url in
+// cmd. An enabled feed starts over with no failures and is fetched on the
+// next run of agg.
+func feedEnableHandler(s *state, cmd command, enable bool) error {
+	feedURL := cmd.Arguments[0]
+
+	var changed int64
+	var err error
+	if enable {
+		changed, err = s.db.EnableFeed(s.ctx, feedURL)
+	} else {
+		changed, err = s.db.DisableFeed(s.ctx, database.DisableFeedParams{
+			DisabledAt: sql.NullTime{Time: time.Now(), Valid: true},
+			Url:        feedURL,
+		})
+	}
+	if err != nil {
+		return dbError(err, "updating feed %s", feedURL)
+	}
+
+	if changed == 0 && enable {
+		return fmt.Errorf("feed %s: %w", feedURL, errNotFound)
+	}
+	if changed == 0 {
+		// DisableFeed leaves disabled feeds alone, tell those from unknown urls
+		_, err := s.db.GetFeedByUrl(s.ctx, feedURL)
+		if err != nil {
+			return dbError(err, "feed %s", feedURL)
+		}
+		fmt.Printf("%s is already disabled\n", feedURL)
+		return nil
+	}
+
+	if enable {
+		fmt.Printf("Enabled %s\n", feedURL)
+	} else {
+		fmt.Printf("Disabled %s\n", feedURL)
+	}
+	return nil
+}
+
+func feedHealthHandler(s *state, cmd command) error {
+	staleAfter := cmd.durationFlag("stale")
+	slowAfter := cmd.durationFlag("slow")
+	if staleAfter <= 0 || slowAfter <= 0 {
+		return invalidArgs("--stale and --slow must be positive")
+	}
+
+	feeds, err := s.db.GetFeedHealth(s.ctx, healthRecentFetches)
+	if err != nil {
+		return dbError(err, "getting feed health")
+	}
+
+	now := time.Now()
+	records := make([]output.Record, 0, len(feeds))
+	for _, feed := range feeds {
+		status := feedHealth(feed, now, staleAfter, slowAfter)
+		if status == healthOK && !cmd.boolFlag("all") {
+			continue
+		}
+		records = append(records, healthRecord(feed, status))
+	}
+	return printRecords(cmd, healthRecord(database.GetFeedHealthRow{}, ""), records)
+}
+
+// feedHealth classifies feed by the worst problem it has: disabled when it
+// is not fetched any more, failing when its latest fetches failed, stale
+// when it was not fetched successfully within staleAfter and slow when its
+// recent fetches took slowAfter on average.
+func feedHealth(feed database.GetFeedHealthRow, now time.Time, staleAfter, slowAfter time.Duration) string {
+	switch {
+	case feed.DisabledAt.Valid:
+		return healthDisabled
+	case feed.ConsecutiveFailures > 0:
+		return healthFailing
+	case !feed.LastFetchedAt.Valid || now.Sub(feed.LastFetchedAt.Time) > staleAfter:
+		return healthStale
+	case time.Duration(feed.AvgDurationMs)*time.Millisecond > slowAfter:
+		return healthSlow
+	default:
+		return healthOK
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/chandanbsd/gator/internal/output"
+)
+
+// newFlagSet returns the flag set of a command with the flags declared by
+// its commandInfo.
+func (info commandInfo) newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if info.Flags != nil {
+		info.Flags(fs)
+	}
+	return fs
+}
+
+// globalFlags declares the options every command accepts, either before
+// the command name or among its own flags.
+func globalFlags(fs *flag.FlagSet) {
+	fs.String("output", string(output.Table), "print listings as `format`: table, json, csv or tsv")
+}
+
+// splitGlobalFlags separates the global options given before the command
+// name, as in gator --output json feeds, from the command line. It returns
+// the command name and its arguments with those options appended.
+func splitGlobalFlags(args []string) (string, []string, error) {
+	fs := flag.NewFlagSet("gator", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	globalFlags(fs)
+
+	err := fs.Parse(args)
+	if err != nil {
+		return "", nil, err
+	}
+
+	rest := fs.Args()
+	if len(rest) == 0 {
+		return "", nil, nil
+	}
+	leading := args[:len(args)-len(rest)]
+	return rest[0], append(rest[1:len(rest):len(rest)], leading...), nil
+}
+
+// parseFlags parses the flags of cmd and leaves the positional arguments in
+// cmd.Arguments. Unlike flag.FlagSet.Parse, flags may come after positional
+// arguments, as in gator addfeed <name> <url> --no-follow.
+func parseFlags(fs *flag.FlagSet, cmd *command) error {
+	var positional []string
+
+	args := cmd.Arguments
+	for {
+		err := fs.Parse(args)
+		if err != nil {
+			return err
+		}
+
+		rest := fs.Args()
+		consumed := args[:len(args)-len(rest)]
+		if len(consumed) > 0 && consumed[len(consumed)-1] == "--" {
+			positional = append(positional, rest...)
+			break
+		}
+		if len(rest) == 0 {
+			break
+		}
+
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+
+	cmd.Arguments = positional
+	cmd.Flags = fs
+	return nil
+}
+
+// printFlags writes the flags of fs in the style of gator help.
+func printFlags(w io.Writer, fs *flag.FlagSet) {
+	fs.VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+		line := "  --" + f.Name
+		if name != "" {
+			line += " " + name
+		}
+		fmt.Fprintf(w, "%s\n    \t%s", line, strings.ReplaceAll(usage, "\n", "\n    \t"))
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" && f.DefValue != "0s" {
+			fmt.Fprintf(w, " (default %s)", f.DefValue)
+		}
+		fmt.Fprintln(w)
+	})
+}
+
+func (c command) flagValue(name string) any {
+	if c.Flags == nil {
+		return nil
+	}
+	f := c.Flags.Lookup(name)
+	if f == nil {
+		return nil
+	}
+	return f.Value.(flag.Getter).Get()
+}
+
+// isSet reports whether the flag was given on the command line, as opposed
+// to holding its default.
+func (c command) isSet(name string) bool {
+	set := false
+	if c.Flags != nil {
+		c.Flags.Visit(func(f *flag.Flag) {
+			if f.Name == name {
+				set = true
+			}
+		})
+	}
+	return set
+}
+
+func (c command) stringFlag(name string) string {
+	v, _ := c.flagValue(name).(string)
+	return v
+}
+
+func (c command) intFlag(name string) int {
+	v, _ := c.flagValue(name).(int)
+	return v
+}
+
+func (c command) boolFlag(name string) bool {
+	v, _ := c.flagValue(name).(bool)
+	return v
+}
+
+func (c command) durationFlag(name string) time.Duration {
+	v, _ := c.flagValue(name).(time.Duration)
+	return v
+}
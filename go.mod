@@ -3,6 +3,7 @@ module github.com/chandanbsd/gator
 go 1.23.9
 
 require (
-	github.com/google/uuid v1.6.0 // indirect
-	github.com/lib/pq v1.10.9 // indirect
+	github.com/andybalholm/brotli v1.2.6
+	github.com/google/uuid v1.6.0
+	github.com/lib/pq v1.10.9
 )
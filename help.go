@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"text/tabwriter"
+)
+
+func (c *commands) helpHandler(s *state, cmd command) error {
+	if len(cmd.Arguments) == 0 {
+		c.printHelp(os.Stdout)
+		return nil
+	}
+
+	info, ok := c.options[cmd.Arguments[0]]
+	if !ok {
+		return invalidArgs("unknown command %q", cmd.Arguments[0])
+	}
+
+	fmt.Printf("Usage: gator %s\n\n%s\n", info.Usage, info.Description)
+	if info.Flags != nil {
+		fmt.Println("\nOptions:")
+		printFlags(os.Stdout, info.newFlagSet(cmd.Arguments[0]))
+	}
+	if info.RequiresLogin {
+		fmt.Println("\nRequires a logged in user, see gator login.")
+	}
+	return nil
+}
+
+// printHelp lists every registered command with its usage and description.
+func (c *commands) printHelp(w io.Writer) {
+	names := make([]string, 0, len(c.options))
+	for name := range c.options {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	fmt.Fprintln(w, "Usage: gator <command> [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, name := range names {
+		info := c.options[name]
+		description := info.Description
+		if info.RequiresLogin {
+			description += " (login required)"
+		}
+		fmt.Fprintf(tw, "  %s\t%s\n", info.Usage, description)
+	}
+	tw.Flush()
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Options for every command:")
+	fs := flag.NewFlagSet("gator", flag.ContinueOnError)
+	globalFlags(fs)
+	printFlags(w, fs)
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run gator help <command> for details on a command.")
+}
@@ -22,7 +22,6 @@ func Read() (Config, error) {
 	}
 	file, err := os.Open(configFilePath)
 	if err != nil {
-		fmt.Println("Cannot open gator config file")
 		return Config{}, err
 	}
 
@@ -30,7 +29,6 @@ func Read() (Config, error) {
 
 	data, err := io.ReadAll(file)
 	if err != nil {
-		fmt.Println("Unable to read the contents of the gator config file")
 		return Config{}, err
 	}
 
@@ -38,8 +36,7 @@ func Read() (Config, error) {
 
 	err = json.Unmarshal(data, &config)
 	if err != nil {
-		fmt.Println("Unable to parse the config file")
-		return Config{}, err
+		return Config{}, fmt.Errorf("parsing %s: %w", configFilePath, err)
 	}
 
 	return config, nil
@@ -19,18 +19,20 @@ WITH inserted_feed_follow AS (
     created_at,
     updated_at,
     feed_id,
-    user_id
+    user_id,
+    folder
   )
   VALUES (
     $1,
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $6
   )
-  RETURNING id, created_at, updated_at, user_id, feed_id
+  RETURNING id, created_at, updated_at, user_id, feed_id, folder
 )
-SELECT inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id,
+SELECT inserted_feed_follow.id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.user_id, inserted_feed_follow.feed_id, inserted_feed_follow.folder,
   feeds.name as feed_name,
   users.name as user_name
 FROM inserted_feed_follow
@@ -44,6 +46,7 @@ type CreateFeedFollowParams struct {
 	UpdatedAt sql.NullTime
 	FeedID    uuid.UUID
 	UserID    uuid.UUID
+	Folder    sql.NullString
 }
 
 type CreateFeedFollowRow struct {
@@ -52,6 +55,7 @@ type CreateFeedFollowRow struct {
 	UpdatedAt sql.NullTime
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Folder    sql.NullString
 	FeedName  string
 	UserName  string
 }
@@ -63,6 +67,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		arg.UpdatedAt,
 		arg.FeedID,
 		arg.UserID,
+		arg.Folder,
 	)
 	var i CreateFeedFollowRow
 	err := row.Scan(
@@ -71,13 +76,14 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.FeedID,
+		&i.Folder,
 		&i.FeedName,
 		&i.UserName,
 	)
 	return i, err
 }
 
-const deleteFeedFollow = `-- name: DeleteFeedFollow :exec
+const deleteFeedFollow = `-- name: DeleteFeedFollow :execrows
 DELETE
 FROM feed_follows
 USING feeds
@@ -90,32 +96,90 @@ type DeleteFeedFollowParams struct {
 	Url    string
 }
 
-func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowParams) error {
-	_, err := q.db.ExecContext(ctx, deleteFeedFollow, arg.UserID, arg.Url)
-	return err
+func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeedFollow, arg.UserID, arg.Url)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getFeedFollowsForExport = `-- name: GetFeedFollowsForExport :many
+SELECT feeds.name, feeds.url, feeds.site_url, feed_follows.folder
+FROM feed_follows
+INNER JOIN feeds on feeds.id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1
+ORDER BY feed_follows.folder NULLS FIRST, feeds.name
+`
+
+type GetFeedFollowsForExportRow struct {
+	Name    string
+	Url     string
+	SiteUrl sql.NullString
+	Folder  sql.NullString
+}
+
+func (q *Queries) GetFeedFollowsForExport(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForExportRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFeedFollowsForExport, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFeedFollowsForExportRow
+	for rows.Next() {
+		var i GetFeedFollowsForExportRow
+		if err := rows.Scan(
+			&i.Name,
+			&i.Url,
+			&i.SiteUrl,
+			&i.Folder,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getFeedFollowsForUser = `-- name: GetFeedFollowsForUser :many
-SELECT feeds.name as feed_name
+SELECT feeds.name as feed_name, feeds.url as feed_url, feed_follows.folder, feed_follows.created_at
 FROM feed_follows
 INNER JOIN feeds on feeds.id = feed_follows.feed_id
 INNER JOIN users on users.id = feed_follows.user_id
 WHERE feed_follows.user_id = $1
 `
 
-func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]string, error) {
+type GetFeedFollowsForUserRow struct {
+	FeedName  string
+	FeedUrl   string
+	Folder    sql.NullString
+	CreatedAt sql.NullTime
+}
+
+func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsForUserRow, error) {
 	rows, err := q.db.QueryContext(ctx, getFeedFollowsForUser, userID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []string
+	var items []GetFeedFollowsForUserRow
 	for rows.Next() {
-		var feed_name string
-		if err := rows.Scan(&feed_name); err != nil {
+		var i GetFeedFollowsForUserRow
+		if err := rows.Scan(
+			&i.FeedName,
+			&i.FeedUrl,
+			&i.Folder,
+			&i.CreatedAt,
+		); err != nil {
 			return nil, err
 		}
-		items = append(items, feed_name)
+		items = append(items, i)
 	}
 	if err := rows.Close(); err != nil {
 		return nil, err
@@ -125,3 +189,24 @@ func (q *Queries) GetFeedFollowsForUser(ctx context.Context, userID uuid.UUID) (
 	}
 	return items, nil
 }
+
+const isFollowingFeed = `-- name: IsFollowingFeed :one
+SELECT EXISTS (
+  SELECT 1
+  FROM feed_follows
+  WHERE user_id = $1
+    AND feed_id = $2
+)
+`
+
+type IsFollowingFeedParams struct {
+	UserID uuid.UUID
+	FeedID uuid.UUID
+}
+
+func (q *Queries) IsFollowingFeed(ctx context.Context, arg IsFollowingFeedParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isFollowingFeed, arg.UserID, arg.FeedID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
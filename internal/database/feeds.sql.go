@@ -19,16 +19,18 @@ INSERT INTO feeds(
 	updated_at,
 	name,
 	url,
-	user_id)
+	user_id,
+	site_url)
 VALUES (
 	$1,
 	$2,
 	$3,
 	$4,
 	$5,
-	$6
+	$6,
+	$7
 )
-RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at
+RETURNING id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, site_url, consecutive_failures, disabled_at
 `
 
 type CreateFeedParams struct {
@@ -38,6 +40,7 @@ type CreateFeedParams struct {
 	Name      string
 	Url       string
 	UserID    uuid.UUID
+	SiteUrl   sql.NullString
 }
 
 func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, error) {
@@ -48,6 +51,7 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		arg.Name,
 		arg.Url,
 		arg.UserID,
+		arg.SiteUrl,
 	)
 	var i Feed
 	err := row.Scan(
@@ -58,10 +62,53 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.Url,
 		&i.UserID,
 		&i.LastFetchedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		&i.SiteUrl,
+		&i.ConsecutiveFailures,
+		&i.DisabledAt,
 	)
 	return i, err
 }
 
+const disableFeed = `-- name: DisableFeed :execrows
+update feeds
+set disabled_at = $1
+where url = $2
+	and disabled_at is null
+`
+
+type DisableFeedParams struct {
+	DisabledAt sql.NullTime
+	Url        string
+}
+
+func (q *Queries) DisableFeed(ctx context.Context, arg DisableFeedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, disableFeed, arg.DisabledAt, arg.Url)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const enableFeed = `-- name: EnableFeed :execrows
+update feeds
+set disabled_at = null,
+	consecutive_failures = 0,
+	next_fetch_at = null
+where url = $1
+`
+
+func (q *Queries) EnableFeed(ctx context.Context, url string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, enableFeed, url)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const getFeedByUrl = `-- name: GetFeedByUrl :one
 select f.ID, f.url
 from feeds f
@@ -115,39 +162,134 @@ func (q *Queries) GetFeeds(ctx context.Context) ([]GetFeedsRow, error) {
 	return items, nil
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-select id, created_at, updated_at, name, url, user_id, last_fetched_at
-from feeds
-order by last_fetched_at desc
+const getNextFeedsToFetch = `-- name: GetNextFeedsToFetch :many
+update feeds
+set next_fetch_at = $1
+where ID in (
+	select ID
+	from feeds
+	where disabled_at is null
+		and (next_fetch_at is null
+			or next_fetch_at <= $2)
+	order by next_fetch_at asc nulls first
+	limit $3
+	for update skip locked
+)
+returning id, created_at, updated_at, name, url, user_id, last_fetched_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, site_url, consecutive_failures, disabled_at
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
-	var i Feed
-	err := row.Scan(
-		&i.ID,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.Name,
-		&i.Url,
-		&i.UserID,
-		&i.LastFetchedAt,
-	)
-	return i, err
+type GetNextFeedsToFetchParams struct {
+	LeaseUntil sql.NullTime
+	DueAt      sql.NullTime
+	MaxFeeds   int32
+}
+
+func (q *Queries) GetNextFeedsToFetch(ctx context.Context, arg GetNextFeedsToFetchParams) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getNextFeedsToFetch, arg.LeaseUntil, arg.DueAt, arg.MaxFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.NextFetchAt,
+			&i.FetchIntervalSeconds,
+			&i.SiteUrl,
+			&i.ConsecutiveFailures,
+			&i.DisabledAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const markFeedFetched = `-- name: MarkFeedFetched :exec
 update feeds
-set last_fetched_at = $1
-where ID = $2
+set last_fetched_at = $1,
+	etag = $2,
+	last_modified = $3,
+	next_fetch_at = $4,
+	fetch_interval_seconds = $5,
+	site_url = $6,
+	consecutive_failures = 0
+where ID = $7
 `
 
 type MarkFeedFetchedParams struct {
-	LastFetchedAt sql.NullTime
-	ID            uuid.UUID
+	LastFetchedAt        sql.NullTime
+	Etag                 sql.NullString
+	LastModified         sql.NullString
+	NextFetchAt          sql.NullTime
+	FetchIntervalSeconds sql.NullInt32
+	SiteUrl              sql.NullString
+	ID                   uuid.UUID
 }
 
 func (q *Queries) MarkFeedFetched(ctx context.Context, arg MarkFeedFetchedParams) error {
-	_, err := q.db.ExecContext(ctx, markFeedFetched, arg.LastFetchedAt, arg.ID)
+	_, err := q.db.ExecContext(ctx, markFeedFetched,
+		arg.LastFetchedAt,
+		arg.Etag,
+		arg.LastModified,
+		arg.NextFetchAt,
+		arg.FetchIntervalSeconds,
+		arg.SiteUrl,
+		arg.ID,
+	)
+	return err
+}
+
+const recordFeedFailure = `-- name: RecordFeedFailure :exec
+update feeds
+set consecutive_failures = consecutive_failures + 1,
+	next_fetch_at = $1,
+	disabled_at = $2
+where ID = $3
+`
+
+type RecordFeedFailureParams struct {
+	NextFetchAt sql.NullTime
+	DisabledAt  sql.NullTime
+	ID          uuid.UUID
+}
+
+func (q *Queries) RecordFeedFailure(ctx context.Context, arg RecordFeedFailureParams) error {
+	_, err := q.db.ExecContext(ctx, recordFeedFailure, arg.NextFetchAt, arg.DisabledAt, arg.ID)
+	return err
+}
+
+const releaseFeed = `-- name: ReleaseFeed :exec
+update feeds
+set next_fetch_at = $1
+where ID = $2
+	and next_fetch_at = $3
+`
+
+type ReleaseFeedParams struct {
+	DueAt      sql.NullTime
+	ID         uuid.UUID
+	LeaseUntil sql.NullTime
+}
+
+func (q *Queries) ReleaseFeed(ctx context.Context, arg ReleaseFeedParams) error {
+	_, err := q.db.ExecContext(ctx, releaseFeed, arg.DueAt, arg.ID, arg.LeaseUntil)
 	return err
 }
@@ -12,13 +12,32 @@ import (
 )
 
 type Feed struct {
+	ID                   uuid.UUID
+	CreatedAt            sql.NullTime
+	UpdatedAt            sql.NullTime
+	Name                 string
+	Url                  string
+	UserID               uuid.UUID
+	LastFetchedAt        sql.NullTime
+	Etag                 sql.NullString
+	LastModified         sql.NullString
+	NextFetchAt          sql.NullTime
+	FetchIntervalSeconds sql.NullInt32
+	SiteUrl              sql.NullString
+	ConsecutiveFailures  int32
+	DisabledAt           sql.NullTime
+}
+
+type FeedFetch struct {
 	ID            uuid.UUID
-	CreatedAt     sql.NullTime
-	UpdatedAt     sql.NullTime
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
+	FeedID        uuid.UUID
+	StartedAt     time.Time
+	DurationMs    int32
+	StatusCode    sql.NullInt32
+	Bytes         sql.NullInt64
+	ItemsParsed   int32
+	ItemsInserted int32
+	Error         sql.NullString
 }
 
 type FeedFollow struct {
@@ -27,6 +46,7 @@ type FeedFollow struct {
 	UpdatedAt sql.NullTime
 	UserID    uuid.UUID
 	FeedID    uuid.UUID
+	Folder    sql.NullString
 }
 
 type Post struct {
@@ -36,8 +56,25 @@ type Post struct {
 	Title       string
 	Url         string
 	Description sql.NullString
-	PublishedAt sql.NullTime
+	PublishedAt time.Time
 	FeedID      uuid.UUID
+	Search      interface{}
+	Guid        sql.NullString
+	DedupKey    string
+	ContentHash string
+	DuplicateOf uuid.NullUUID
+}
+
+type PostRead struct {
+	UserID uuid.UUID
+	PostID uuid.UUID
+	ReadAt time.Time
+}
+
+type PostStar struct {
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	StarredAt time.Time
 }
 
 type User struct {
@@ -11,83 +11,262 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-const createPost = `-- name: CreatePost :exec
-INSERT INTO posts(
-    id,
-	created_at,
-	updated_at,
-	title,
-	url,
-	description,
-	published_at,
-	feed_id)
-VALUES
-(
-    $1,
-    $2,
-    $3,
-    $4,
-    $5,
-    $6,
-    $7,
-    $8
+const adoptPostGUIDs = `-- name: AdoptPostGUIDs :exec
+UPDATE posts p
+SET guid = a.guid,
+    dedup_key = a.dedup_key
+FROM unnest(
+    $1::text[],
+    $2::text[],
+    $3::text[]
+) AS a(guid, dedup_key, url)
+WHERE p.feed_id = $4
+    AND p.guid IS NULL
+    AND p.dedup_key = 'url:' || a.url
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts g
+        WHERE g.feed_id = $4
+            AND g.dedup_key = a.dedup_key
+    )
+`
+
+type AdoptPostGUIDsParams struct {
+	Guids     []string
+	DedupKeys []string
+	Urls      []string
+	FeedID    uuid.UUID
+}
+
+func (q *Queries) AdoptPostGUIDs(ctx context.Context, arg AdoptPostGUIDsParams) error {
+	_, err := q.db.ExecContext(ctx, adoptPostGUIDs,
+		pq.Array(arg.Guids),
+		pq.Array(arg.DedupKeys),
+		pq.Array(arg.Urls),
+		arg.FeedID,
+	)
+	return err
+}
+
+const getNewerPostsForUser = `-- name: GetNewerPostsForUser :many
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id,
+    f.name AS feed_name,
+    EXISTS (
+        SELECT 1
+        FROM post_reads pr
+        WHERE pr.post_id = p.id
+            AND pr.user_id = $1
+    ) AS read
+FROM posts p
+INNER JOIN feeds f ON f.id = p.feed_id
+WHERE EXISTS (
+    SELECT 1
+    FROM feed_follows ff
+    WHERE ff.feed_id = p.feed_id
+        AND ff.user_id = $1
 )
+    AND ($2::text IS NULL OR f.url = $2)
+    AND ($3::timestamptz IS NULL OR p.published_at >= $3)
+    AND ($4::boolean OR NOT EXISTS (
+        SELECT 1
+        FROM post_reads pr
+        WHERE pr.post_id = p.id
+            AND pr.user_id = $1
+    ))
+    AND (p.published_at, p.id) > ($5::timestamptz, $6::uuid)
+ORDER BY p.published_at, p.id
+LIMIT $7
 `
 
-type CreatePostParams struct {
+type GetNewerPostsForUserParams struct {
+	UserID            uuid.UUID
+	FeedUrl           sql.NullString
+	Since             sql.NullTime
+	IncludeRead       bool
+	BeforePublishedAt time.Time
+	BeforeID          uuid.UUID
+	Limit             int32
+}
+
+type GetNewerPostsForUserRow struct {
 	ID          uuid.UUID
 	CreatedAt   time.Time
 	UpdatedAt   sql.NullTime
 	Title       string
 	Url         string
 	Description sql.NullString
-	PublishedAt sql.NullTime
+	PublishedAt time.Time
 	FeedID      uuid.UUID
+	FeedName    string
+	Read        bool
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) error {
-	_, err := q.db.ExecContext(ctx, createPost,
-		arg.ID,
-		arg.CreatedAt,
-		arg.UpdatedAt,
-		arg.Title,
-		arg.Url,
-		arg.Description,
-		arg.PublishedAt,
-		arg.FeedID,
+func (q *Queries) GetNewerPostsForUser(ctx context.Context, arg GetNewerPostsForUserParams) ([]GetNewerPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getNewerPostsForUser,
+		arg.UserID,
+		arg.FeedUrl,
+		arg.Since,
+		arg.IncludeRead,
+		arg.BeforePublishedAt,
+		arg.BeforeID,
+		arg.Limit,
 	)
-	return err
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNewerPostsForUserRow
+	for rows.Next() {
+		var i GetNewerPostsForUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Title,
+			&i.Url,
+			&i.Description,
+			&i.PublishedAt,
+			&i.FeedID,
+			&i.FeedName,
+			&i.Read,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostIDsByPrefix = `-- name: GetPostIDsByPrefix :many
+SELECT p.id
+FROM posts p
+WHERE p.id::text LIKE $1::text || '%'
+    AND (
+        EXISTS (
+            SELECT 1
+            FROM feed_follows ff
+            WHERE ff.feed_id = p.feed_id
+                AND ff.user_id = $2
+        )
+        OR EXISTS (
+            SELECT 1
+            FROM post_stars ps
+            WHERE ps.post_id = p.id
+                AND ps.user_id = $2
+        )
+    )
+ORDER BY p.id
+LIMIT 2
+`
+
+type GetPostIDsByPrefixParams struct {
+	Prefix string
+	UserID uuid.UUID
+}
+
+func (q *Queries) GetPostIDsByPrefix(ctx context.Context, arg GetPostIDsByPrefixParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getPostIDsByPrefix, arg.Prefix, arg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const getPostsForUser = `-- name: GetPostsForUser :many
-SELECT id, created_at, updated_at, title, url, description, published_at, feed_id
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id,
+    f.name AS feed_name,
+    EXISTS (
+        SELECT 1
+        FROM post_reads pr
+        WHERE pr.post_id = p.id
+            AND pr.user_id = $1
+    ) AS read
 FROM posts p
+INNER JOIN feeds f ON f.id = p.feed_id
 WHERE EXISTS (
     SELECT 1
     FROM feed_follows ff
     WHERE ff.feed_id = p.feed_id
         AND ff.user_id = $1
 )
-ORDER BY published_at desc
-LIMIT $2
+    AND ($2::text IS NULL OR f.url = $2)
+    AND ($3::timestamptz IS NULL OR p.published_at >= $3)
+    AND ($4::boolean OR NOT EXISTS (
+        SELECT 1
+        FROM post_reads pr
+        WHERE pr.post_id = p.id
+            AND pr.user_id = $1
+    ))
+    AND ($5::timestamptz IS NULL
+        OR (p.published_at, p.id) < ($5, $6::uuid))
+ORDER BY p.published_at DESC, p.id DESC
+LIMIT $7
 `
 
 type GetPostsForUserParams struct {
-	UserID uuid.UUID
-	Limit  int32
+	UserID           uuid.UUID
+	FeedUrl          sql.NullString
+	Since            sql.NullTime
+	IncludeRead      bool
+	AfterPublishedAt sql.NullTime
+	AfterID          uuid.NullUUID
+	Limit            int32
+}
+
+type GetPostsForUserRow struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   sql.NullTime
+	Title       string
+	Url         string
+	Description sql.NullString
+	PublishedAt time.Time
+	FeedID      uuid.UUID
+	FeedName    string
+	Read        bool
 }
 
-func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]Post, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.UserID, arg.Limit)
+func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUser,
+		arg.UserID,
+		arg.FeedUrl,
+		arg.Since,
+		arg.IncludeRead,
+		arg.AfterPublishedAt,
+		arg.AfterID,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Post
+	var items []GetPostsForUserRow
 	for rows.Next() {
-		var i Post
+		var i GetPostsForUserRow
 		if err := rows.Scan(
 			&i.ID,
 			&i.CreatedAt,
@@ -97,6 +276,8 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Description,
 			&i.PublishedAt,
 			&i.FeedID,
+			&i.FeedName,
+			&i.Read,
 		); err != nil {
 			return nil, err
 		}
@@ -110,3 +291,188 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const searchPosts = `-- name: SearchPosts :many
+SELECT p.id, p.title, p.url, p.published_at, f.name AS feed_name,
+    ts_rank(p.search, q.query) AS rank,
+    ts_headline(
+        'english',
+        coalesce(p.description, p.title),
+        q.query,
+        'StartSel=«, StopSel=», MaxWords=30, MinWords=12, MaxFragments=2'
+    )::text AS snippet
+FROM posts p
+INNER JOIN feeds f ON f.id = p.feed_id
+CROSS JOIN (SELECT to_tsquery('english', $1::text) AS query) q
+WHERE p.search @@ q.query
+    AND ($2::boolean OR EXISTS (
+        SELECT 1
+        FROM feed_follows ff
+        WHERE ff.feed_id = p.feed_id
+            AND ff.user_id = $3
+    ))
+ORDER BY rank desc, p.published_at desc
+LIMIT $4
+`
+
+type SearchPostsParams struct {
+	Query    string
+	AllFeeds bool
+	UserID   uuid.UUID
+	Limit    int32
+}
+
+type SearchPostsRow struct {
+	ID          uuid.UUID
+	Title       string
+	Url         string
+	PublishedAt time.Time
+	FeedName    string
+	Rank        float32
+	Snippet     string
+}
+
+func (q *Queries) SearchPosts(ctx context.Context, arg SearchPostsParams) ([]SearchPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPosts,
+		arg.Query,
+		arg.AllFeeds,
+		arg.UserID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchPostsRow
+	for rows.Next() {
+		var i SearchPostsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Url,
+			&i.PublishedAt,
+			&i.FeedName,
+			&i.Rank,
+			&i.Snippet,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertPosts = `-- name: UpsertPosts :many
+INSERT INTO posts(
+    id,
+    created_at,
+    updated_at,
+    title,
+    url,
+    description,
+    published_at,
+    feed_id,
+    guid,
+    dedup_key,
+    content_hash,
+    duplicate_of)
+SELECT
+    i.id,
+    $1::timestamp,
+    NULL,
+    i.title,
+    i.url,
+    NULLIF(i.description, ''),
+    i.published_at,
+    $2::uuid,
+    NULLIF(i.guid, ''),
+    i.dedup_key,
+    i.content_hash,
+    (
+        SELECT d.id
+        FROM posts d
+        WHERE d.url = i.url
+            AND d.url <> ''
+            AND d.feed_id <> $2
+            AND d.duplicate_of IS NULL
+        ORDER BY d.created_at
+        LIMIT 1
+    )
+FROM unnest(
+    $3::uuid[],
+    $4::text[],
+    $5::text[],
+    $6::text[],
+    $7::timestamptz[],
+    $8::text[],
+    $9::text[],
+    $10::text[]
+) AS i(id, title, url, description, published_at, guid, dedup_key, content_hash)
+ON CONFLICT (feed_id, dedup_key) DO UPDATE
+SET title = excluded.title,
+    url = excluded.url,
+    description = excluded.description,
+    content_hash = excluded.content_hash,
+    updated_at = CASE WHEN posts.content_hash = '' THEN posts.updated_at ELSE excluded.created_at END
+WHERE posts.content_hash <> excluded.content_hash
+RETURNING id, (xmax = 0)::boolean AS inserted, (updated_at IS NOT DISTINCT FROM $1::timestamp)::boolean AS changed
+`
+
+type UpsertPostsParams struct {
+	CreatedAt     time.Time
+	FeedID        uuid.UUID
+	Ids           []uuid.UUID
+	Titles        []string
+	Urls          []string
+	Descriptions  []string
+	PublishedAts  []time.Time
+	Guids         []string
+	DedupKeys     []string
+	ContentHashes []string
+}
+
+type UpsertPostsRow struct {
+	ID       uuid.UUID
+	Inserted bool
+	Changed  bool
+}
+
+func (q *Queries) UpsertPosts(ctx context.Context, arg UpsertPostsParams) ([]UpsertPostsRow, error) {
+	rows, err := q.db.QueryContext(ctx, upsertPosts,
+		arg.CreatedAt,
+		arg.FeedID,
+		pq.Array(arg.Ids),
+		pq.Array(arg.Titles),
+		pq.Array(arg.Urls),
+		pq.Array(arg.Descriptions),
+		pq.Array(arg.PublishedAts),
+		pq.Array(arg.Guids),
+		pq.Array(arg.DedupKeys),
+		pq.Array(arg.ContentHashes),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []UpsertPostsRow
+	for rows.Next() {
+		var i UpsertPostsRow
+		if err := rows.Scan(&i.ID, &i.Inserted, &i.Changed); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,97 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+type AtomFeed struct {
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle"`
+	Links    []AtomLink  `xml:"link"`
+	Entries  []AtomEntry `xml:"entry"`
+}
+
+type AtomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Links     []AtomLink `xml:"link"`
+	Summary   AtomText   `xml:"summary"`
+	Content   AtomText   `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+}
+
+// AtomText is an Atom text construct. For type="xhtml" the content is markup
+// rather than escaped text, so the inner XML is kept as is.
+type AtomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t AtomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return t.Text
+}
+
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+func parseAtom(data []byte) (*Feed, error) {
+	var atomFeed AtomFeed
+
+	err := xml.Unmarshal(data, &atomFeed)
+	if err != nil {
+		return nil, err
+	}
+
+	f := Feed{
+		Title:       atomFeed.Title,
+		Link:        alternateLink(atomFeed.Links),
+		Description: atomFeed.Subtitle,
+		Items:       make([]Item, 0, len(atomFeed.Entries)),
+	}
+
+	for _, entry := range atomFeed.Entries {
+		description := entry.Summary.String()
+		if strings.TrimSpace(description) == "" {
+			description = entry.Content.String()
+		}
+
+		pubDate := entry.Published
+		if pubDate == "" {
+			pubDate = entry.Updated
+		}
+
+		f.Items = append(f.Items, Item{
+			ID:          entry.ID,
+			Title:       entry.Title,
+			Link:        alternateLink(entry.Links),
+			Description: description,
+			PubDate:     pubDate,
+		})
+	}
+
+	return &f, nil
+}
+
+// alternateLink returns the href of the rel="alternate" link. Atom treats a
+// link without a rel attribute as alternate, and if neither is present the
+// first link is used.
+func alternateLink(links []AtomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+	if len(links) > 0 {
+		return links[0].Href
+	}
+	return ""
+}
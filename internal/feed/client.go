@@ -0,0 +1,262 @@
+package feed
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Defaults of ClientOptions. They keep a stuck or oversized feed from
+// holding a worker, or the memory of a small machine, for long.
+const (
+	DefaultConnectTimeout = 10 * time.Second
+	DefaultReadTimeout    = 30 * time.Second
+	DefaultTimeout        = time.Minute
+	DefaultMaxBodySize    = 10 << 20
+	DefaultMaxRedirects   = 5
+
+	// drainLimit is how much of an error response is read, so that its
+	// connection can be reused, before the connection is given up.
+	drainLimit = 64 << 10
+)
+
+// Kinds of fetch errors, test for them with errors.Is.
+var (
+	// ErrTimeout is returned when the server was too slow to connect, to
+	// answer or to send the body.
+	ErrTimeout = errors.New("timed out")
+	// ErrTooLarge is returned when the body, once decompressed, is larger
+	// than the maximum body size.
+	ErrTooLarge = errors.New("feed too large")
+	// ErrBadStatus is returned when the server answered with an error
+	// status.
+	ErrBadStatus = errors.New("request failed")
+)
+
+// acceptEncoding lists the compressions Client.Fetch decodes.
+const acceptEncoding = "gzip, br"
+
+// ClientOptions configure a Client. Zero fields take the defaults above.
+type ClientOptions struct {
+	// ConnectTimeout bounds connecting to the server, TLS handshake
+	// included.
+	ConnectTimeout time.Duration
+	// ReadTimeout bounds waiting for the response headers and for each
+	// read of the body.
+	ReadTimeout time.Duration
+	// Timeout bounds a whole fetch, redirects and body included.
+	Timeout time.Duration
+	// MaxBodySize is the most bytes of body read, after decompression.
+	MaxBodySize int64
+	// MaxRedirects is how many redirects a fetch follows.
+	MaxRedirects int
+	// NoRedirects makes a fetch stop at the first redirect, whatever
+	// MaxRedirects says.
+	NoRedirects bool
+}
+
+// Client fetches feeds. It keeps connections open between fetches, so one
+// Client should be shared by everything fetching feeds.
+type Client struct {
+	http        *http.Client
+	readTimeout time.Duration
+	maxBodySize int64
+}
+
+// NewClient returns a Client configured by opts.
+func NewClient(opts ClientOptions) *Client {
+	if opts.ConnectTimeout <= 0 {
+		opts.ConnectTimeout = DefaultConnectTimeout
+	}
+	if opts.ReadTimeout <= 0 {
+		opts.ReadTimeout = DefaultReadTimeout
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxBodySize <= 0 {
+		opts.MaxBodySize = DefaultMaxBodySize
+	}
+	if opts.MaxRedirects <= 0 {
+		opts.MaxRedirects = DefaultMaxRedirects
+	}
+	if opts.NoRedirects {
+		opts.MaxRedirects = 0
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   opts.ConnectTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   opts.ConnectTimeout,
+		ResponseHeaderTimeout: opts.ReadTimeout,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   4,
+		IdleConnTimeout:       90 * time.Second,
+		// Fetch asks for and decodes the compressions itself.
+		DisableCompression: true,
+	}
+
+	maxRedirects := opts.MaxRedirects
+	return &Client{
+		http: &http.Client{
+			Transport: transport,
+			Timeout:   opts.Timeout,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) > maxRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxRedirects)
+				}
+				return nil
+			},
+		},
+		readTimeout: opts.ReadTimeout,
+		maxBodySize: opts.MaxBodySize,
+	}
+}
+
+// Fetch downloads and parses the feed at feedURL. The validators of the
+// previous fetch, if any, are sent so that an unchanged feed costs a 304
+// instead of the whole body.
+func (c *Client) Fetch(ctx context.Context, feedURL string, validators Validators) (*Feed, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", "gator")
+	req.Header.Set("Accept", acceptHeader)
+	req.Header.Set("Accept-Encoding", acceptEncoding)
+	if validators.ETag != "" {
+		req.Header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validators.LastModified)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, timeoutError(err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotModified {
+		return &Feed{
+			NotModified: true,
+			Validators:  responseValidators(res, validators),
+			StatusCode:  res.StatusCode,
+		}, nil
+	}
+
+	if res.StatusCode > 299 {
+		fetchErr := &FetchError{StatusCode: res.StatusCode, Err: fmt.Errorf("%w: %s", ErrBadStatus, res.Status)}
+		if res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusServiceUnavailable {
+			fetchErr.RetryAfter = retryAfter(res.Header.Get("Retry-After"), time.Now())
+		}
+		io.CopyN(io.Discard, res.Body, drainLimit)
+		return nil, fetchErr
+	}
+
+	bodyBytes, err := c.readBody(res, cancel)
+	if err != nil {
+		return nil, &FetchError{StatusCode: res.StatusCode, Size: int64(len(bodyBytes)), Err: err}
+	}
+
+	f, err := Parse(res.Header.Get("Content-Type"), bodyBytes)
+	if err != nil {
+		return nil, &FetchError{StatusCode: res.StatusCode, Size: int64(len(bodyBytes)), Err: err}
+	}
+
+	f.Validators = responseValidators(res, Validators{})
+	f.StatusCode = res.StatusCode
+	f.Size = int64(len(bodyBytes))
+
+	return f, nil
+}
+
+// readBody reads the decompressed body of res, at most c.maxBodySize bytes
+// of it. cancel aborts the request and is called when a read of the body
+// takes longer than c.readTimeout.
+func (c *Client) readBody(res *http.Response, cancel context.CancelFunc) ([]byte, error) {
+	// A compressed body only grows when it is decoded.
+	if res.ContentLength > c.maxBodySize {
+		return nil, fmt.Errorf("%w: %d bytes, the limit is %d", ErrTooLarge, res.ContentLength, c.maxBodySize)
+	}
+
+	var stalled atomic.Bool
+	timer := time.AfterFunc(c.readTimeout, func() {
+		stalled.Store(true)
+		cancel()
+	})
+	defer timer.Stop()
+
+	var body io.Reader = &stallReader{r: res.Body, timer: timer, timeout: c.readTimeout}
+	switch encoding := strings.ToLower(strings.TrimSpace(res.Header.Get("Content-Encoding"))); encoding {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, readError(err, stalled.Load())
+		}
+		defer gz.Close()
+		body = gz
+	case "br":
+		body = brotli.NewReader(body)
+	default:
+		return nil, fmt.Errorf("unsupported content encoding %q", encoding)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, c.maxBodySize+1))
+	if err != nil {
+		return data, readError(err, stalled.Load())
+	}
+	if int64(len(data)) > c.maxBodySize {
+		return data, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, c.maxBodySize)
+	}
+	return data, nil
+}
+
+// stallReader restarts timer with timeout before every read of r, so the
+// timer only fires when a single read stalls.
+type stallReader struct {
+	r       io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func (s *stallReader) Read(p []byte) (int, error) {
+	s.timer.Reset(s.timeout)
+	return s.r.Read(p)
+}
+
+// readError is timeoutError for errors reading a body, which were caused by
+// a timeout too if the body stalled.
+func readError(err error, stalled bool) error {
+	if stalled {
+		return fmt.Errorf("%w: no data for too long: %w", ErrTimeout, err)
+	}
+	return timeoutError(err)
+}
+
+// timeoutError marks err as an ErrTimeout if it is a network timeout.
+func timeoutError(err error) error {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return err
+}
@@ -0,0 +1,139 @@
+package feed
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// dateLayouts are tried in order, most common first. Named zones are
+// rewritten to numeric offsets by normalizeDate before parsing, so only
+// numeric zone layouts are needed here. Fractional seconds after the
+// seconds field parse with any layout.
+var dateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC3339,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 02 Jan 2006 15:04 -0700",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"02 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 -0700",
+	"02 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04 -0700",
+	"Mon, 02 Jan 06 15:04:05 -0700",
+	"Mon, 2 Jan 06 15:04:05 -0700",
+	"Mon, 02 Jan 06 15:04 -0700",
+	time.RFC822Z,
+	"2 Jan 06 15:04 -0700",
+	"02 Jan 06 15:04:05 -0700",
+	"Monday, 02-Jan-06 15:04:05 -0700",
+	"Mon, 02 Jan 2006 15:04:05",
+	"02 Jan 2006 15:04:05",
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	"Mon, 02 Jan 2006",
+	"Mon, 2 Jan 2006",
+	"02 Jan 2006",
+	"2 Jan 2006",
+	time.RubyDate,
+	time.UnixDate,
+	time.ANSIC,
+}
+
+// namedZones maps the zone names RFC 822 allows, plus a few common ones seen
+// in the wild, to their offsets. time.Parse only knows the abbreviations of
+// the local zone and silently treats any other name as UTC.
+var namedZones = map[string]string{
+	"UT":   "+0000",
+	"UTC":  "+0000",
+	"GMT":  "+0000",
+	"Z":    "+0000",
+	"EST":  "-0500",
+	"EDT":  "-0400",
+	"CST":  "-0600",
+	"CDT":  "-0500",
+	"MST":  "-0700",
+	"MDT":  "-0600",
+	"PST":  "-0800",
+	"PDT":  "-0700",
+	"AKST": "-0900",
+	"AKDT": "-0800",
+	"HST":  "-1000",
+	"BST":  "+0100",
+	"IST":  "+0530",
+	"CET":  "+0100",
+	"CEST": "+0200",
+	"EET":  "+0200",
+	"EEST": "+0300",
+	"JST":  "+0900",
+	"KST":  "+0900",
+	"AEST": "+1000",
+	"AEDT": "+1100",
+}
+
+// ParseDate parses a feed date in any of the layouts used by RSS, Atom and
+// JSON Feed publishers. Dates without a zone are taken as UTC, and every
+// date is returned in UTC.
+func ParseDate(value string) (time.Time, error) {
+	value = normalizeDate(value)
+	if value == "" {
+		return time.Time{}, errors.New("Empty date")
+	}
+
+	for _, layout := range dateLayouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, errors.New("Unrecognized date: " + value)
+}
+
+// normalizeDate collapses whitespace, drops a trailing comment such as the
+// "(UTC)" in "+0000 (UTC)" and rewrites a trailing zone name, "GMT+hh:mm"
+// or "+hh:mm" zone as a "+hhmm" offset.
+func normalizeDate(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, ")") {
+		if i := strings.LastIndex(value, "("); i >= 0 {
+			value = value[:i]
+		}
+	}
+
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	last := strings.ToUpper(fields[len(fields)-1])
+	if offset, ok := namedZones[last]; ok {
+		fields[len(fields)-1] = offset
+	} else if len(last) > 3 && (strings.HasPrefix(last, "GMT") || strings.HasPrefix(last, "UTC")) {
+		fields[len(fields)-1] = strings.ReplaceAll(last[3:], ":", "")
+	} else if len(last) == 6 && (last[0] == '+' || last[0] == '-') && last[3] == ':' {
+		fields[len(fields)-1] = strings.ReplaceAll(last, ":", "")
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// normalizeDates fills in Published for every item. Items whose date is
+// missing or unparseable get the fetch time and are flagged, so one odd
+// entry does not hold back the rest of the feed.
+func (f *Feed) normalizeDates(fetchedAt time.Time) {
+	for i := range f.Items {
+		published, err := ParseDate(f.Items[i].PubDate)
+		if err != nil {
+			f.Items[i].Published = fetchedAt.UTC()
+			f.Items[i].PublishedEstimated = true
+			continue
+		}
+		f.Items[i].Published = published
+	}
+}
@@ -1,68 +1,260 @@
 package feed
 
 import (
-	"context"
+	"bytes"
 	"encoding/xml"
 	"errors"
 	"html"
-	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Feed is the format independent view of a fetched feed. RSS 2.0, RSS 1.0,
+// Atom and JSON Feed documents are all converted into it.
+type Feed struct {
+	Title       string
+	Link        string
+	Description string
+	Items       []Item
+
+	// UpdateInterval is how often the publisher says the feed changes, from
+	// RSS <ttl> or <sy:updatePeriod>. It is zero when there is no hint.
+	UpdateInterval time.Duration
+
+	// NotModified is set when the server answered a conditional request
+	// with 304 Not Modified. The feed then has no items.
+	NotModified bool
+	// Validators are the cache validators to send on the next fetch.
+	Validators Validators
+
+	// StatusCode and Size describe the response the feed was read from.
+	StatusCode int
+	Size       int64
+}
+
+// FetchError is returned by Client.Fetch when the server answered but the
+// feed could not be read, with what is known of the response. Err tells
+// the kind of failure apart, see ErrBadStatus and ErrTooLarge.
+type FetchError struct {
+	StatusCode int
+	// Size is the number of body bytes read.
+	Size int64
+	// RetryAfter is how long the server asked to wait before the next
+	// request, from Retry-After on 429 and 503 responses, or zero.
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *FetchError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.Err
+}
+
+// Validators are the HTTP cache validators of a previous response, used to
+// make conditional requests with If-None-Match and If-Modified-Since.
+type Validators struct {
+	ETag         string
+	LastModified string
+}
+
+// Item is a single entry of a Feed.
+type Item struct {
+	ID          string
+	Title       string
+	Link        string
+	Description string
+	PubDate     string
+	// Published is PubDate parsed by ParseDate. When PubDate could not be
+	// parsed it holds the fetch time and PublishedEstimated is set.
+	Published          time.Time
+	PublishedEstimated bool
+}
+
 type RSSFeed struct {
 	Channel struct {
-		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
-		Description string    `xml:"description"`
-		Item        []RSSItem `xml:"item"`
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		Description string `xml:"description"`
+		TTL         string `xml:"ttl"`
+		// Syndication module hints, see https://web.resource.org/rss/1.0/modules/syndication/
+		UpdatePeriod    string    `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string    `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+		Item            []RSSItem `xml:"item"`
 	} `xml:"channel"`
 }
 
 type RSSItem struct {
+	GUID        string `xml:"guid"`
 	Title       string `xml:"title"`
 	Link        string `xml:"link"`
 	Description string `xml:"description"`
 	PubDate     string `xml:"pubDate"`
 }
 
-func FetchFeed(ctx context.Context, feedURL string) (*RSSFeed, error) {
-	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+// retryAfter parses a Retry-After header, either a number of seconds or an
+// HTTP date, into the time to wait from now. It returns zero when the header
+// is missing, invalid or in the past.
+func retryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(header); err == nil {
+		return max(date.Sub(now), 0)
+	}
+	return 0
+}
+
+// responseValidators reads the validators of a response. A 304 may omit
+// them, in which case the ones that were sent stay valid.
+func responseValidators(res *http.Response, previous Validators) Validators {
+	validators := previous
+	if etag := res.Header.Get("ETag"); etag != "" {
+		validators.ETag = etag
+	}
+	if lastModified := res.Header.Get("Last-Modified"); lastModified != "" {
+		validators.LastModified = lastModified
+	}
+	return validators
+}
+
+// Parse converts a feed document into a Feed. The format is picked from the
+// Content-Type when it is specific enough, otherwise from a sniff of the body.
+func Parse(contentType string, data []byte) (*Feed, error) {
+	var f *Feed
+	var err error
+
+	switch detectFormat(contentType, data) {
+	case formatJSON:
+		f, err = parseJSONFeed(data)
+	case formatXML:
+		f, err = parseXML(data)
+	default:
+		return nil, errors.New("Unsupported feed content type: " + contentType)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("User-Agent", "gator")
+	f.normalizeDates(time.Now())
 
-	client := &http.Client{}
+	return f, nil
+}
 
-	res, err := client.Do(req)
+// parseXML detects the format of an XML feed document from its root element
+// and converts it into a Feed.
+func parseXML(data []byte) (*Feed, error) {
+	root, err := rootElement(data)
 	if err != nil {
 		return nil, err
 	}
-	defer res.Body.Close()
 
-	bodyBytes, err := io.ReadAll(res.Body)
+	var f *Feed
+	switch root {
+	case "rss":
+		f, err = parseRSS(data)
+	case "RDF":
+		f, err = parseRDF(data)
+	case "feed":
+		f, err = parseAtom(data)
+	default:
+		return nil, errors.New("Unsupported feed format: " + root)
+	}
 	if err != nil {
 		return nil, err
 	}
-	if res.StatusCode > 299 {
-		return nil, errors.New("Request failed")
+
+	f.Title = html.UnescapeString(f.Title)
+	f.Description = html.UnescapeString(f.Description)
+
+	for i := range f.Items {
+		f.Items[i].Title = html.UnescapeString(f.Items[i].Title)
+		f.Items[i].Description = html.UnescapeString(f.Items[i].Description)
+	}
+
+	return f, nil
+}
+
+func rootElement(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
 	}
+}
 
+func parseRSS(data []byte) (*Feed, error) {
 	var rssFeed RSSFeed
 
-	err = xml.Unmarshal(bodyBytes, &rssFeed)
+	err := xml.Unmarshal(data, &rssFeed)
 	if err != nil {
 		return nil, err
 	}
 
-	rssFeed.Channel.Title = html.UnescapeString(rssFeed.Channel.Title)
-	rssFeed.Channel.Description = html.UnescapeString(rssFeed.Channel.Description)
+	f := Feed{
+		Title:       rssFeed.Channel.Title,
+		Link:        rssFeed.Channel.Link,
+		Description: rssFeed.Channel.Description,
+		Items:       make([]Item, 0, len(rssFeed.Channel.Item)),
+
+		UpdateInterval: rssUpdateInterval(rssFeed.Channel.TTL, rssFeed.Channel.UpdatePeriod, rssFeed.Channel.UpdateFrequency),
+	}
+
+	for _, item := range rssFeed.Channel.Item {
+		f.Items = append(f.Items, Item{
+			ID:          item.GUID,
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Description,
+			PubDate:     item.PubDate,
+		})
+	}
+
+	return &f, nil
+}
+
+// rssUpdateInterval turns the <ttl> minutes or the <sy:updatePeriod> and
+// <sy:updateFrequency> pair into a duration. Values that do not parse are
+// ignored, as if the feed gave no hint.
+func rssUpdateInterval(ttl, updatePeriod, updateFrequency string) time.Duration {
+	minutes, err := strconv.Atoi(strings.TrimSpace(ttl))
+	if err == nil && minutes > 0 {
+		return time.Duration(minutes) * time.Minute
+	}
+
+	var period time.Duration
+	switch strings.ToLower(strings.TrimSpace(updatePeriod)) {
+	case "hourly":
+		period = time.Hour
+	case "daily":
+		period = 24 * time.Hour
+	case "weekly":
+		period = 7 * 24 * time.Hour
+	case "monthly":
+		period = 30 * 24 * time.Hour
+	case "yearly":
+		period = 365 * 24 * time.Hour
+	default:
+		return 0
+	}
 
-	for i, _ := range rssFeed.Channel.Item {
-		rssFeed.Channel.Item[i].Title = html.UnescapeString(rssFeed.Channel.Item[i].Title)
-		rssFeed.Channel.Item[i].Description = html.UnescapeString(rssFeed.Channel.Item[i].Description)
+	frequency, err := strconv.Atoi(strings.TrimSpace(updateFrequency))
+	if err != nil || frequency < 1 {
+		frequency = 1
 	}
 
-	return &rssFeed, nil
+	return period / time.Duration(frequency)
 }
@@ -0,0 +1,149 @@
+package feed
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2015, 10, 21, 7, 20, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+	}{
+		{"missing", "", 0},
+		{"seconds", "120", 2 * time.Minute},
+		{"seconds with spaces", " 30 ", 30 * time.Second},
+		{"zero seconds", "0", 0},
+		{"negative seconds", "-5", 0},
+		{"http date", "Wed, 21 Oct 2015 07:28:00 GMT", 8 * time.Minute},
+		{"rfc 850 date", "Wednesday, 21-Oct-15 07:28:00 GMT", 8 * time.Minute},
+		{"asctime date", "Wed Oct 21 07:28:00 2015", 8 * time.Minute},
+		{"date in the past", "Wed, 21 Oct 2015 07:00:00 GMT", 0},
+		{"garbage", "soon", 0},
+		{"fractional seconds", "1.5", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryAfter(tt.header, now); got != tt.want {
+				t.Errorf("retryAfter(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Time
+	}{
+		{"rfc 1123z", "Tue, 02 Jan 2024 03:04:05 +0000", want},
+		{"rfc 1123 gmt", "Tue, 02 Jan 2024 03:04:05 GMT", want},
+		{"named zone", "Mon, 01 Jan 2024 22:04:05 EST", want},
+		{"single digit day", "Tue, 2 Jan 2024 05:04:05 +0200", want},
+		{"no seconds", "Tue, 02 Jan 2024 03:04 +0000", want.Truncate(time.Minute)},
+		{"two digit year", "Tue, 02 Jan 24 03:04:05 +0000", want},
+		{"zone comment", "Tue, 02 Jan 2024 03:04:05 +0000 (UTC)", want},
+		{"gmt offset", "Tue, 02 Jan 2024 05:04:05 GMT+02:00", want},
+		{"colon offset", "Tue, 02 Jan 2024 05:04:05 +02:00", want},
+		{"extra whitespace", "  Tue,  02 Jan 2024\t03:04:05 +0000 ", want},
+		{"rfc 3339", "2024-01-02T03:04:05Z", want},
+		{"rfc 3339 offset", "2024-01-02T05:04:05+02:00", want},
+		{"rfc 3339 fraction", "2024-01-02T03:04:05.123Z", want.Add(123 * time.Millisecond)},
+		{"iso 8601 basic offset", "2024-01-02T05:04:05+0200", want},
+		{"iso 8601 basic offset fraction", "2024-01-02T05:04:05.5+0200", want.Add(500 * time.Millisecond)},
+		{"iso 8601 no zone", "2024-01-02T03:04:05", want},
+		{"sql", "2024-01-02 03:04:05", want},
+		{"date only", "2024-01-02", day},
+		{"rss date only", "02 Jan 2024", day},
+		{"rss date only with weekday", "Tue, 2 Jan 2024", day},
+		{"unix date", "Tue Jan  2 03:04:05 UTC 2024", want},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDate(tt.value)
+			if err != nil {
+				t.Fatalf("ParseDate(%q): %v", tt.value, err)
+			}
+			if !got.Equal(tt.want) || got.Location() != time.UTC {
+				t.Errorf("ParseDate(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	for _, value := range []string{"", "   ", "yesterday", "2024-13-45"} {
+		if got, err := ParseDate(value); err == nil {
+			t.Errorf("ParseDate(%q) = %v, want an error", value, got)
+		}
+	}
+}
+
+func TestParseRDF(t *testing.T) {
+	const doc = `<?xml version="1.0" encoding="UTF-8"?>
+<rdf:RDF
+  xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+  xmlns:dc="http://purl.org/dc/elements/1.1/"
+  xmlns:sy="http://purl.org/rss/1.0/modules/syndication/"
+  xmlns="http://purl.org/rss/1.0/">
+  <channel rdf:about="https://example.com/">
+    <title>Example &amp; Co</title>
+    <link>https://example.com/</link>
+    <description>News</description>
+    <sy:updatePeriod>hourly</sy:updatePeriod>
+    <sy:updateFrequency>2</sy:updateFrequency>
+    <items>
+      <rdf:Seq>
+        <rdf:li rdf:resource="https://example.com/1"/>
+        <rdf:li rdf:resource="https://example.com/2"/>
+      </rdf:Seq>
+    </items>
+  </channel>
+  <item rdf:about="https://example.com/1">
+    <title>First</title>
+    <link>https://example.com/1</link>
+    <description>One &lt;b&gt;bold&lt;/b&gt;</description>
+    <dc:date>2024-01-02T05:04:05+02:00</dc:date>
+  </item>
+  <item rdf:about="https://example.com/2">
+    <title>Second</title>
+    <link>https://example.com/2</link>
+  </item>
+</rdf:RDF>`
+
+	f, err := Parse("application/rdf+xml", []byte(doc))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if f.Title != "Example & Co" || f.Link != "https://example.com/" || f.Description != "News" {
+		t.Errorf("channel = %q %q %q", f.Title, f.Link, f.Description)
+	}
+	if f.UpdateInterval != 30*time.Minute {
+		t.Errorf("UpdateInterval = %v, want 30m", f.UpdateInterval)
+	}
+	if len(f.Items) != 2 {
+		t.Fatalf("got %d items, want 2", len(f.Items))
+	}
+
+	first := f.Items[0]
+	if first.ID != "https://example.com/1" || first.Title != "First" || first.Link != "https://example.com/1" {
+		t.Errorf("first item = %+v", first)
+	}
+	if first.Description != "One <b>bold</b>" {
+		t.Errorf("first description = %q", first.Description)
+	}
+	if want := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC); !first.Published.Equal(want) || first.PublishedEstimated {
+		t.Errorf("first published = %v (estimated %v), want %v", first.Published, first.PublishedEstimated, want)
+	}
+	if !f.Items[1].PublishedEstimated {
+		t.Errorf("second item without a date is not estimated")
+	}
+}
@@ -0,0 +1,62 @@
+package feed
+
+import (
+	"bytes"
+	"mime"
+	"strings"
+)
+
+type format int
+
+const (
+	formatUnknown format = iota
+	formatXML
+	formatJSON
+)
+
+const acceptHeader = "application/rss+xml, application/atom+xml, application/feed+json, application/xml;q=0.9, application/json;q=0.8, */*;q=0.5"
+
+// detectFormat decides which parser a response body goes to. Feed specific
+// media types are trusted as is; generic ones such as text/plain or
+// application/octet-stream, which plenty of servers send for feeds, fall back
+// to looking at the first non-blank byte of the body. That also covers feeds
+// mislabelled as text/html, while real HTML pages are still rejected.
+func detectFormat(contentType string, data []byte) format {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil {
+		switch {
+		case mediaType == "application/feed+json":
+			return formatJSON
+		case mediaType == "application/rss+xml",
+			mediaType == "application/atom+xml",
+			mediaType == "application/rdf+xml":
+			return formatXML
+		}
+	}
+
+	return sniffFormat(data)
+}
+
+func sniffFormat(data []byte) format {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	data = bytes.TrimLeft(data, " \t\r\n")
+
+	switch {
+	case len(data) == 0:
+		return formatUnknown
+	case data[0] == '{':
+		return formatJSON
+	case data[0] == '<' && !isHTML(data):
+		return formatXML
+	default:
+		return formatUnknown
+	}
+}
+
+func isHTML(data []byte) bool {
+	if len(data) > 512 {
+		data = data[:512]
+	}
+	prefix := strings.ToLower(string(data))
+	return strings.HasPrefix(prefix, "<!doctype html") || strings.HasPrefix(prefix, "<html")
+}
@@ -0,0 +1,27 @@
+package feed
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Key identifies the item within its feed across fetches. It is the RSS
+// <guid>, Atom <id> or JSON Feed id when there is one, else the link, else
+// the content hash. The prefix keeps the kinds from colliding.
+func (i Item) Key() string {
+	if id := strings.TrimSpace(i.ID); id != "" {
+		return "guid:" + id
+	}
+	if strings.TrimSpace(i.Link) != "" {
+		return "url:" + i.Link
+	}
+	return "hash:" + i.ContentHash()
+}
+
+// ContentHash is a hash of the title and description, used to tell whether
+// an item changed since it was stored.
+func (i Item) ContentHash() string {
+	sum := sha256.Sum256([]byte(i.Title + "\n" + i.Description))
+	return hex.EncodeToString(sum[:])
+}
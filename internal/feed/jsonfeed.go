@@ -0,0 +1,89 @@
+package feed
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// JSONFeed is a JSON Feed 1.1 document, see https://www.jsonfeed.org/version/1.1/
+type JSONFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description"`
+	Items       []JSONFeedItem `json:"items"`
+}
+
+type JSONFeedItem struct {
+	ID            json.RawMessage `json:"id"`
+	URL           string          `json:"url"`
+	ExternalURL   string          `json:"external_url"`
+	Title         string          `json:"title"`
+	ContentHTML   string          `json:"content_html"`
+	ContentText   string          `json:"content_text"`
+	Summary       string          `json:"summary"`
+	DatePublished string          `json:"date_published"`
+	DateModified  string          `json:"date_modified"`
+}
+
+func parseJSONFeed(data []byte) (*Feed, error) {
+	var jsonFeed JSONFeed
+
+	err := json.Unmarshal(data, &jsonFeed)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(jsonFeed.Version, "https://jsonfeed.org/version/") {
+		return nil, errors.New("Unsupported JSON feed version: " + jsonFeed.Version)
+	}
+
+	f := Feed{
+		Title:       jsonFeed.Title,
+		Link:        jsonFeed.HomePageURL,
+		Description: jsonFeed.Description,
+		Items:       make([]Item, 0, len(jsonFeed.Items)),
+	}
+
+	for _, item := range jsonFeed.Items {
+		description := item.Summary
+		if description == "" {
+			description = item.ContentHTML
+		}
+		if description == "" {
+			description = item.ContentText
+		}
+
+		link := item.URL
+		if link == "" {
+			link = item.ExternalURL
+		}
+
+		pubDate := item.DatePublished
+		if pubDate == "" {
+			pubDate = item.DateModified
+		}
+
+		f.Items = append(f.Items, Item{
+			ID:          jsonFeedID(item.ID),
+			Title:       item.Title,
+			Link:        link,
+			Description: description,
+			PubDate:     pubDate,
+		})
+	}
+
+	return &f, nil
+}
+
+// jsonFeedID returns the item id as a string. The spec requires a string but
+// some publishers emit numbers, which are kept in their JSON form.
+func jsonFeedID(raw json.RawMessage) string {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return id
+	}
+	return string(raw)
+}
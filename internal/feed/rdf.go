@@ -0,0 +1,56 @@
+package feed
+
+import "encoding/xml"
+
+// RDFFeed is an RSS 1.0 document, see https://web.resource.org/rss/1.0/spec.
+// Unlike RSS 2.0 its items are siblings of the channel, not children, and
+// their dates come from the Dublin Core module.
+type RDFFeed struct {
+	Channel struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		Description string `xml:"description"`
+		// Syndication module hints, see https://web.resource.org/rss/1.0/modules/syndication/
+		UpdatePeriod    string `xml:"http://purl.org/rss/1.0/modules/syndication/ updatePeriod"`
+		UpdateFrequency string `xml:"http://purl.org/rss/1.0/modules/syndication/ updateFrequency"`
+	} `xml:"channel"`
+	Items []RDFItem `xml:"item"`
+}
+
+type RDFItem struct {
+	About       string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	Date        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+}
+
+func parseRDF(data []byte) (*Feed, error) {
+	var rdfFeed RDFFeed
+
+	err := xml.Unmarshal(data, &rdfFeed)
+	if err != nil {
+		return nil, err
+	}
+
+	f := Feed{
+		Title:       rdfFeed.Channel.Title,
+		Link:        rdfFeed.Channel.Link,
+		Description: rdfFeed.Channel.Description,
+		Items:       make([]Item, 0, len(rdfFeed.Items)),
+
+		UpdateInterval: rssUpdateInterval("", rdfFeed.Channel.UpdatePeriod, rdfFeed.Channel.UpdateFrequency),
+	}
+
+	for _, item := range rdfFeed.Items {
+		f.Items = append(f.Items, Item{
+			ID:          item.About,
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Description,
+			PubDate:     item.Date,
+		})
+	}
+
+	return &f, nil
+}
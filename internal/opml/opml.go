@@ -0,0 +1,152 @@
+package opml
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+)
+
+// OPML is an OPML 2.0 document, see http://opml.org/spec2.opml
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    Head     `xml:"head"`
+	Body    Body     `xml:"body"`
+}
+
+type Head struct {
+	Title       string `xml:"title"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type Body struct {
+	Outlines []Outline `xml:"outline"`
+}
+
+// Outline is either a subscription, when XMLURL is set, or a folder holding
+// more outlines.
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Subscription is a feed outline together with the folders it is nested in.
+type Subscription struct {
+	Title   string
+	XMLURL  string
+	HTMLURL string
+	// Folder is the path of folder outlines above the feed joined by "/",
+	// empty for top level feeds.
+	Folder string
+}
+
+// New builds an OPML document from subscriptions, nesting them in folder
+// outlines according to their Folder.
+func New(title string, subscriptions []Subscription) *OPML {
+	doc := OPML{
+		Version: "2.0",
+		Head: Head{
+			Title:       title,
+			DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+
+	for _, subscription := range subscriptions {
+		outlines := &doc.Body.Outlines
+		if subscription.Folder != "" {
+			for _, folder := range strings.Split(subscription.Folder, "/") {
+				outlines = &folderOutline(outlines, folder).Outlines
+			}
+		}
+
+		*outlines = append(*outlines, Outline{
+			Text:    subscription.Title,
+			Title:   subscription.Title,
+			Type:    "rss",
+			XMLURL:  subscription.XMLURL,
+			HTMLURL: subscription.HTMLURL,
+		})
+	}
+
+	return &doc
+}
+
+// folderOutline returns the folder outline called name in outlines, adding
+// it if there is none yet.
+func folderOutline(outlines *[]Outline, name string) *Outline {
+	for i := range *outlines {
+		if (*outlines)[i].XMLURL == "" && (*outlines)[i].Text == name {
+			return &(*outlines)[i]
+		}
+	}
+	*outlines = append(*outlines, Outline{Text: name, Title: name})
+	return &(*outlines)[len(*outlines)-1]
+}
+
+// Write encodes the document as indented XML.
+func (o *OPML) Write(w io.Writer) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	err = encoder.Encode(o)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+func Parse(r io.Reader) (*OPML, error) {
+	var doc OPML
+
+	err := xml.NewDecoder(r).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return &doc, nil
+}
+
+// Subscriptions flattens the outline tree into the feeds it contains, in
+// document order.
+func (o *OPML) Subscriptions() []Subscription {
+	var subscriptions []Subscription
+	collect(o.Body.Outlines, nil, &subscriptions)
+	return subscriptions
+}
+
+func collect(outlines []Outline, folders []string, subscriptions *[]Subscription) {
+	for _, outline := range outlines {
+		if outline.XMLURL != "" {
+			*subscriptions = append(*subscriptions, Subscription{
+				Title:   outline.name(),
+				XMLURL:  strings.TrimSpace(outline.XMLURL),
+				HTMLURL: strings.TrimSpace(outline.HTMLURL),
+				Folder:  strings.Join(folders, "/"),
+			})
+		}
+
+		if len(outline.Outlines) > 0 {
+			collect(outline.Outlines, append(folders[:len(folders):len(folders)], outline.name()), subscriptions)
+		}
+	}
+}
+
+// name returns the title of the outline, falling back to its text as most
+// readers only fill in one of them.
+func (o Outline) name() string {
+	if o.Title != "" {
+		return o.Title
+	}
+	return o.Text
+}
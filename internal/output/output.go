@@ -0,0 +1,174 @@
+package output
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Format is how listing commands print their records.
+type Format string
+
+const (
+	// Table is aligned columns for people to read, the default.
+	Table Format = "table"
+	JSON  Format = "json"
+	CSV   Format = "csv"
+	TSV   Format = "tsv"
+)
+
+// Formats lists the supported formats, for help and error messages.
+var Formats = []Format{Table, JSON, CSV, TSV}
+
+// ParseFormat returns the format called name.
+func ParseFormat(name string) (Format, error) {
+	for _, format := range Formats {
+		if string(format) == strings.ToLower(name) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown output format %q, use one of %s", name, formatNames())
+}
+
+func formatNames() string {
+	names := make([]string, len(Formats))
+	for i, format := range Formats {
+		names[i] = string(format)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Field is one named value of a Record. Names are the snake_case column
+// names of the database row the record comes from, so they stay the same
+// across releases and formats.
+type Field struct {
+	Name  string
+	Value any
+}
+
+// Record is a row of output. Every record written together should have
+// the same fields in the same order.
+type Record []Field
+
+// MarshalJSON writes the record as an object, keeping the field order.
+func (r Record) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, field := range r {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		name, err := json.Marshal(field.Name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(plain(field.Value))
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", field.Name, err)
+		}
+		b.Write(name)
+		b.WriteByte(':')
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// Write prints records to w in format. JSON is an array of objects, CSV and
+// TSV have a header line with the field names. An empty list still prints
+// an empty array or a header if there is one to print.
+func Write(w io.Writer, format Format, header []string, records []Record) error {
+	switch format {
+	case JSON:
+		if records == nil {
+			records = []Record{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+	case CSV:
+		cw := csv.NewWriter(w)
+		cw.Write(header)
+		for _, record := range records {
+			cw.Write(record.strings(time.RFC3339))
+		}
+		cw.Flush()
+		return cw.Error()
+	case TSV:
+		_, err := fmt.Fprintln(w, strings.Join(header, "\t"))
+		for _, record := range records {
+			if err != nil {
+				break
+			}
+			_, err = fmt.Fprintln(w, strings.Join(singleLine(record.strings(time.RFC3339)), "\t"))
+		}
+		return err
+	default:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(header, "\t")))
+		for _, record := range records {
+			fmt.Fprintln(tw, strings.Join(singleLine(record.strings("2006-01-02 15:04")), "\t"))
+		}
+		return tw.Flush()
+	}
+}
+
+// Header returns the field names of record, for Write.
+func Header(record Record) []string {
+	names := make([]string, len(record))
+	for i, field := range record {
+		names[i] = field.Name
+	}
+	return names
+}
+
+// singleLine replaces the tabs and line breaks in values, which would break
+// the columns of tables and TSV, with spaces.
+func singleLine(values []string) []string {
+	for i, value := range values {
+		values[i] = strings.Join(strings.FieldsFunc(value, func(r rune) bool {
+			return r == '\t' || r == '\n' || r == '\r'
+		}), " ")
+	}
+	return values
+}
+
+// strings formats the values of r as text, with times in layout and
+// missing values empty.
+func (r Record) strings(layout string) []string {
+	values := make([]string, len(r))
+	for i, field := range r {
+		switch v := plain(field.Value).(type) {
+		case nil:
+		case time.Time:
+			if layout == time.RFC3339 {
+				values[i] = v.Format(layout)
+			} else {
+				values[i] = v.Local().Format(layout)
+			}
+		default:
+			values[i] = fmt.Sprint(v)
+		}
+	}
+	return values
+}
+
+// plain unwraps the sql.Null types of database rows into their value, or
+// nil when they are not valid.
+func plain(value any) any {
+	if valuer, ok := value.(driver.Valuer); ok {
+		if _, isStringer := value.(fmt.Stringer); !isStringer {
+			v, err := valuer.Value()
+			if err == nil {
+				return v
+			}
+		}
+	}
+	return value
+}
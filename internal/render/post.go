@@ -0,0 +1,120 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// maxTextWidth keeps descriptions readable on wide terminals.
+const maxTextWidth = 100
+
+const (
+	bold  = "\x1b[1m"
+	dim   = "\x1b[2m"
+	cyan  = "\x1b[36m"
+	reset = "\x1b[0m"
+)
+
+// Post is what the renderer shows of a post.
+type Post struct {
+	ID       string
+	FeedName string
+	Title    string
+	URL      string
+	// Published is the zero time when the publish date is unknown.
+	Published time.Time
+	// Description may be HTML or plain text.
+	Description string
+	Read        bool
+}
+
+// PostRenderer writes posts for a person to read.
+type PostRenderer struct {
+	Terminal Terminal
+	// MaxDescription caps the length of descriptions in characters. Zero
+	// shows them in full.
+	MaxDescription int
+	// Now is the time published dates are relative to.
+	Now time.Time
+}
+
+// Render writes post to w: a header with the feed name and published date,
+// the title and link, and the description as wrapped plain text, followed by
+// a blank line.
+func (r PostRenderer) Render(w io.Writer, post Post) error {
+	published := "date unknown"
+	if !post.Published.IsZero() {
+		local := post.Published.Local()
+		published = fmt.Sprintf("%s (%s)", local.Format("Mon 2 Jan 2006 15:04"), Relative(local, r.Now))
+	}
+
+	header := fmt.Sprintf("%s · %s", post.FeedName, published)
+	if post.ID != "" {
+		header = post.ID + "  " + header
+	}
+	if post.Read {
+		header += " · read"
+	}
+
+	width := min(r.Terminal.Width, maxTextWidth)
+
+	_, err := fmt.Fprintf(w, "%s\n%s\n%s\n",
+		r.style(cyan, header),
+		r.style(bold, Wrap(post.Title, width, "")),
+		r.style(dim, post.URL),
+	)
+	if err != nil {
+		return err
+	}
+
+	text := Truncate(HTMLToText(post.Description), r.MaxDescription)
+	if text != "" {
+		_, err = fmt.Fprintf(w, "\n%s\n", Wrap(text, width, "  "))
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintln(w)
+	return err
+}
+
+func (r PostRenderer) style(code, s string) string {
+	if !r.Terminal.Color || s == "" {
+		return s
+	}
+	return code + s + reset
+}
+
+// Relative describes t relative to now in words, e.g. "3 hours ago".
+func Relative(t, now time.Time) string {
+	d := now.Sub(t)
+	suffix := "ago"
+	if d < 0 {
+		d = -d
+		suffix = "from now"
+	}
+
+	var n int
+	var unit string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		n, unit = int(d/time.Minute), "minute"
+	case d < 24*time.Hour:
+		n, unit = int(d/time.Hour), "hour"
+	case d < 30*24*time.Hour:
+		n, unit = int(d/(24*time.Hour)), "day"
+	case d < 365*24*time.Hour:
+		n, unit = int(d/(30*24*time.Hour)), "month"
+	default:
+		n, unit = int(d/(365*24*time.Hour)), "year"
+	}
+
+	if n != 1 {
+		unit += "s"
+	}
+	return fmt.Sprintf("%d %s %s", n, unit, suffix)
+}
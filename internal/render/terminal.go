@@ -0,0 +1,45 @@
+package render
+
+import (
+	"os"
+	"strconv"
+)
+
+// defaultWidth is used when output is not a terminal or its size is
+// unknown.
+const defaultWidth = 80
+
+// Terminal describes where output is going.
+type Terminal struct {
+	// Width is the number of columns to fit lines into.
+	Width int
+	// Color reports whether ANSI escapes may be used.
+	Color bool
+}
+
+// DetectTerminal inspects f, usually os.Stdout. Color is only used when f is
+// a terminal and NO_COLOR is not set. The width comes from the terminal,
+// then from COLUMNS, then defaultWidth.
+func DetectTerminal(f *os.File) Terminal {
+	t := Terminal{Width: defaultWidth}
+
+	tty := IsTerminal(f)
+	t.Color = tty && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb"
+
+	if width, _, ok := Size(f); tty && ok && width > 0 {
+		t.Width = width
+	} else if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 0 {
+		t.Width = columns
+	}
+
+	return t
+}
+
+// IsTerminal reports whether f is a terminal rather than a file or pipe.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,10 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package render
+
+import "os"
+
+// Size is not supported here, so the width falls back to COLUMNS.
+func Size(f *os.File) (width, height int, ok bool) {
+	return 0, 0, false
+}
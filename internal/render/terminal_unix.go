@@ -0,0 +1,21 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package render
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Size asks the terminal driver for the number of columns and rows of f.
+func Size(f *os.File) (width, height int, ok bool) {
+	var size struct {
+		Rows, Cols, Xpixel, Ypixel uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&size)))
+	if errno != 0 {
+		return 0, 0, false
+	}
+	return int(size.Cols), int(size.Rows), true
+}
@@ -0,0 +1,206 @@
+package render
+
+import (
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// blockTags end a paragraph, so their text is kept apart from the text
+// around them.
+var blockTags = map[string]bool{
+	"p": true, "div": true, "ul": true, "ol": true, "dl": true, "dd": true, "dt": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "pre": true, "table": true, "tr": true, "hr": true,
+	"section": true, "article": true, "header": true, "footer": true,
+	"figure": true, "figcaption": true,
+}
+
+// skippedTags have content that is not meant to be read.
+var skippedTags = map[string]bool{
+	"script": true, "style": true, "head": true, "noscript": true, "template": true,
+}
+
+// HTMLToText converts an HTML fragment, as found in feed descriptions, to
+// plain text. Tags are dropped, entities decoded and whitespace collapsed;
+// block elements become paragraphs separated by a blank line, <br> a line
+// break and list items lines starting with "- ". Plain text goes through
+// unchanged apart from the whitespace.
+func HTMLToText(s string) string {
+	if !strings.ContainsRune(s, '<') {
+		return normalizeParagraphs(html.UnescapeString(s))
+	}
+
+	var b strings.Builder
+	skipping := ""
+
+	for s != "" {
+		start := strings.IndexByte(s, '<')
+		if start < 0 {
+			start = len(s)
+		}
+		if skipping == "" {
+			b.WriteString(collapseSpace(html.UnescapeString(s[:start])))
+		}
+		s = s[start:]
+		if s == "" {
+			break
+		}
+
+		if strings.HasPrefix(s, "<!--") {
+			end := strings.Index(s, "-->")
+			if end < 0 {
+				break
+			}
+			s = s[end+len("-->"):]
+			continue
+		}
+
+		end := strings.IndexByte(s, '>')
+		if end < 0 || !startsTag(s) {
+			// A lone < is text, not the start of a tag.
+			if skipping == "" {
+				b.WriteByte('<')
+			}
+			s = s[1:]
+			continue
+		}
+		name, closing := tagName(s[1:end])
+		s = s[end+1:]
+
+		switch {
+		case skipping != "":
+			if closing && name == skipping {
+				skipping = ""
+			}
+		case skippedTags[name] && !closing:
+			skipping = name
+		case name == "br":
+			b.WriteString("\n")
+		case name == "li" && !closing:
+			b.WriteString("\n- ")
+		case blockTags[name]:
+			b.WriteString("\n\n")
+		}
+	}
+
+	return normalizeParagraphs(b.String())
+}
+
+// tagName returns the lower-case name of the tag whose text between < and >
+// is tag, and whether it is a closing tag.
+func tagName(tag string) (string, bool) {
+	closing := strings.HasPrefix(tag, "/")
+	tag = strings.TrimPrefix(tag, "/")
+	end := strings.IndexAny(tag, " \t\r\n/")
+	if end >= 0 {
+		tag = tag[:end]
+	}
+	return strings.ToLower(tag), closing
+}
+
+// startsTag reports whether s, which starts with <, starts a tag rather
+// than being text like "a < b".
+func startsTag(s string) bool {
+	if len(s) < 2 {
+		return false
+	}
+	c := s[1]
+	return c == '/' || c == '!' || c == '?' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+// collapseSpace turns every run of whitespace in s, line breaks included,
+// into a single space. Line breaks in HTML source carry no meaning.
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// normalizeParagraphs trims every line and collapses runs of blank lines
+// into a single one.
+func normalizeParagraphs(s string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(s, "\n") {
+		line = strings.Join(strings.Fields(line), " ")
+		if line == "" || line == "-" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Truncate shortens s to at most limit characters, cutting at a word
+// boundary where there is one and marking the cut with an ellipsis. A limit
+// of zero or less leaves s as it is.
+func Truncate(s string, limit int) string {
+	if limit <= 0 || utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+
+	runes := []rune(s)
+	cut := string(runes[:limit-1])
+	if i := strings.LastIndexAny(cut, " \n"); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " \n.,;:") + "…"
+}
+
+// Wrap breaks every line of s into lines of at most width characters,
+// prefixing each with indent. Words longer than a line, like long URLs, get
+// a line of their own rather than being split.
+func Wrap(s string, width int, indent string) string {
+	width -= utf8.RuneCountInString(indent)
+	if width < 20 {
+		width = 20
+	}
+
+	var b strings.Builder
+	for i, line := range strings.Split(s, "\n") {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		if line == "" {
+			continue
+		}
+
+		b.WriteString(indent)
+		used := 0
+		for _, word := range strings.Fields(line) {
+			n := utf8.RuneCountInString(word)
+			if used > 0 && used+1+n > width {
+				b.WriteString("\n" + indent)
+				used = 0
+			}
+			if used > 0 {
+				b.WriteByte(' ')
+				used++
+			}
+			b.WriteString(word)
+			used += n
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package schedule
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Policy decides how long to wait before fetching a feed again.
+type Policy struct {
+	// Default is the interval of a feed that has not been fetched yet.
+	Default time.Duration
+	// Min and Max bound every interval the policy hands out.
+	Min time.Duration
+	Max time.Duration
+	// MaxFailures is how many fetches in a row may fail before the feed is
+	// disabled, zero to keep retrying forever.
+	MaxFailures int
+}
+
+const (
+	speedUpFactor = 0.5
+	backOffFactor = 1.5
+)
+
+// Next returns the interval to use after a fetch. current is the interval
+// the feed was fetched with, zero if it has none yet, newItems is how many
+// posts the fetch added and hint is the publisher's update interval, zero
+// if the feed does not give one.
+//
+// Feeds that published something since the last fetch are polled twice as
+// often, feeds that did not are backed off by half again, so the interval
+// settles around how often the feed actually publishes. A hint is honored
+// as a lower bound: the publisher asked not to be polled more often.
+func (p Policy) Next(current time.Duration, newItems int, hint time.Duration) time.Duration {
+	next := p.Default
+	if current > 0 {
+		if newItems > 0 {
+			next = time.Duration(float64(current) * speedUpFactor)
+		} else {
+			next = time.Duration(float64(current) * backOffFactor)
+		}
+	}
+
+	if next < hint {
+		next = hint
+	}
+
+	return p.clamp(next)
+}
+
+// Retry returns the interval to wait after the failures-th fetch in a row
+// failed. It starts at Min and doubles with every failure, randomized by
+// up to half so that feeds failing together spread out again. retryAfter
+// is how long the server asked to wait, zero if it did not, and is honored
+// as a lower bound like the hint of Next.
+func (p Policy) Retry(failures int, retryAfter time.Duration) time.Duration {
+	next := p.Min
+	for i := 1; i < failures && (p.Max <= 0 || next < p.Max); i++ {
+		next *= 2
+	}
+	if p.Max > 0 && next > p.Max {
+		next = p.Max
+	}
+	next -= time.Duration(rand.Int64N(int64(next/2) + 1))
+
+	if next < retryAfter {
+		next = retryAfter
+	}
+
+	return p.clamp(next)
+}
+
+// Disabled reports whether a feed is to be disabled after failures fetches
+// in a row failed.
+func (p Policy) Disabled(failures int) bool {
+	return p.MaxFailures > 0 && failures >= p.MaxFailures
+}
+
+func (p Policy) clamp(d time.Duration) time.Duration {
+	if d < p.Min {
+		return p.Min
+	}
+	if p.Max > 0 && d > p.Max {
+		return p.Max
+	}
+	return d
+}
@@ -0,0 +1,105 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	p := Policy{Default: time.Minute, Min: time.Minute, Max: time.Hour}
+
+	tests := []struct {
+		name       string
+		failures   int
+		retryAfter time.Duration
+		min, max   time.Duration
+	}{
+		{"first failure waits Min", 1, 0, time.Minute, time.Minute},
+		{"no failures waits Min", 0, 0, time.Minute, time.Minute},
+		{"second failure doubles", 2, 0, time.Minute, 2 * time.Minute},
+		{"third failure doubles again", 3, 0, 2 * time.Minute, 4 * time.Minute},
+		{"capped at Max", 10, 0, 30 * time.Minute, time.Hour},
+		{"many failures do not overflow", 1000, 0, 30 * time.Minute, time.Hour},
+		{"retry after is a lower bound", 1, 10 * time.Minute, 10 * time.Minute, 10 * time.Minute},
+		{"shorter retry after is ignored", 3, time.Second, 2 * time.Minute, 4 * time.Minute},
+		{"retry after is capped at Max", 1, 3 * time.Hour, time.Hour, time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The jitter is random, every draw has to stay in bounds.
+			for range 100 {
+				got := p.Retry(tt.failures, tt.retryAfter)
+				if got < tt.min || got > tt.max {
+					t.Fatalf("Retry(%d, %v) = %v, want between %v and %v", tt.failures, tt.retryAfter, got, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryJitters(t *testing.T) {
+	p := Policy{Min: time.Minute, Max: time.Hour}
+
+	seen := make(map[time.Duration]bool)
+	for range 100 {
+		seen[p.Retry(5, 0)] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("Retry(5, 0) returned %d distinct intervals over 100 calls, want jitter", len(seen))
+	}
+}
+
+func TestDisabled(t *testing.T) {
+	tests := []struct {
+		maxFailures int
+		failures    int
+		want        bool
+	}{
+		{0, 1000, false},
+		{10, 9, false},
+		{10, 10, true},
+		{10, 11, true},
+		{1, 1, true},
+	}
+
+	for _, tt := range tests {
+		p := Policy{MaxFailures: tt.maxFailures}
+		if got := p.Disabled(tt.failures); got != tt.want {
+			t.Errorf("Policy{MaxFailures: %d}.Disabled(%d) = %v, want %v", tt.maxFailures, tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	p := Policy{Default: 4 * time.Minute, Min: time.Minute, Max: time.Hour}
+
+	tests := []struct {
+		name     string
+		current  time.Duration
+		newItems int
+		hint     time.Duration
+		want     time.Duration
+	}{
+		{"new feed starts at Default", 0, 0, 0, 4 * time.Minute},
+		{"new busy feed starts at Default", 0, 5, 0, 4 * time.Minute},
+		{"quiet feed backs off", 4 * time.Minute, 0, 0, 6 * time.Minute},
+		{"quiet feed backs off up to Max", 50 * time.Minute, 0, 0, time.Hour},
+		{"busy feed speeds up", 4 * time.Minute, 3, 0, 2 * time.Minute},
+		{"busy feed speeds up below Default", 2 * time.Minute, 1, 0, time.Minute},
+		{"busy feed speeds up down to Min", time.Minute, 1, 0, time.Minute},
+		{"backed off feed speeds up again", time.Hour, 1, 0, 30 * time.Minute},
+		{"ttl is a lower bound", 4 * time.Minute, 3, 10 * time.Minute, 10 * time.Minute},
+		{"ttl bounds a new feed", 0, 0, 10 * time.Minute, 10 * time.Minute},
+		{"shorter ttl is ignored", 4 * time.Minute, 0, time.Minute, 6 * time.Minute},
+		{"ttl is capped at Max", 4 * time.Minute, 0, 3 * time.Hour, time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Next(tt.current, tt.newItems, tt.hint); got != tt.want {
+				t.Errorf("Next(%v, %d, %v) = %v, want %v", tt.current, tt.newItems, tt.hint, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// ToTSQuery converts a search as typed by a user into to_tsquery syntax.
+//
+// Words must all match. "quoted words" must appear as a phrase, a trailing
+// * makes a word a prefix, a leading - excludes a word or a whole phrase
+// and OR between two terms matches either of them. Everything else that
+// means something to to_tsquery is dropped, so the result is always a
+// valid query.
+func ToTSQuery(input string) (string, error) {
+	var terms []string
+	joinWithOr := false
+
+	for _, token := range tokenize(input) {
+		if !token.phrase && !token.negate && token.text == "OR" {
+			if len(terms) > 0 {
+				joinWithOr = true
+			}
+			continue
+		}
+
+		term := token.term()
+		if term == "" {
+			continue
+		}
+
+		if len(terms) > 0 {
+			if joinWithOr {
+				terms[len(terms)-1] = "(" + terms[len(terms)-1] + " | " + term + ")"
+				joinWithOr = false
+				continue
+			}
+		}
+		terms = append(terms, term)
+	}
+
+	if len(terms) == 0 {
+		return "", errors.New("the search has no words to look for")
+	}
+
+	return strings.Join(terms, " & "), nil
+}
+
+type token struct {
+	text   string
+	phrase bool
+	negate bool
+}
+
+func tokenize(input string) []token {
+	var tokens []token
+
+	for {
+		input = strings.TrimSpace(input)
+		if input == "" {
+			return tokens
+		}
+
+		// A leading - excludes the word or phrase that follows.
+		var t token
+		if input[0] == '-' {
+			t.negate = true
+			input = strings.TrimLeft(input, "-")
+		}
+
+		if input != "" && input[0] == '"' {
+			t.phrase = true
+			end := strings.IndexByte(input[1:], '"')
+			if end < 0 {
+				t.text = input[1:]
+				return append(tokens, t)
+			}
+			t.text = input[1 : end+1]
+			tokens = append(tokens, t)
+			input = input[end+2:]
+			continue
+		}
+
+		end := strings.IndexFunc(input, unicode.IsSpace)
+		if end < 0 {
+			end = len(input)
+		}
+		t.text = input[:end]
+		tokens = append(tokens, t)
+		input = input[end:]
+	}
+}
+
+func (t token) term() string {
+	text := t.text
+	prefix := false
+	if !t.phrase {
+		prefix = strings.HasSuffix(text, "*")
+		text = strings.Trim(text, "-*")
+	}
+
+	words := lexemes(text)
+	if len(words) == 0 {
+		return ""
+	}
+
+	term := strings.Join(words, " <-> ")
+	if prefix {
+		term += ":*"
+	}
+	if len(words) > 1 && (!t.phrase || t.negate) {
+		term = "(" + term + ")"
+	}
+	if t.negate {
+		term = "!" + term
+	}
+	return term
+}
+
+// lexemes splits text into the runs of letters and digits it contains,
+// which is also how Postgres' parser sees words like "e-mail" or "c++".
+func lexemes(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
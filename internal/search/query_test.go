@@ -0,0 +1,52 @@
+package search
+
+import "testing"
+
+func TestToTSQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"word", "golang", "golang"},
+		{"words all match", "go generics", "go & generics"},
+		{"lower cased", "Go", "go"},
+		{"phrase", `"error handling"`, "error <-> handling"},
+		{"unclosed phrase", `"error handling`, "error <-> handling"},
+		{"prefix", "gener*", "gener:*"},
+		{"negated word", "go -java", "go & !java"},
+		{"negated phrase", `go -"error handling"`, "go & !(error <-> handling)"},
+		{"negated single word phrase", `go -"java"`, "go & !java"},
+		{"negated prefix", "go -jav*", "go & !jav:*"},
+		{"or", "go OR rust", "(go | rust)"},
+		{"or with phrase", `"error handling" OR panic`, "(error <-> handling | panic)"},
+		{"or binds to its neighbours", "web go OR rust", "web & (go | rust)"},
+		{"leading or is dropped", "OR go", "go"},
+		{"trailing or is dropped", "go OR", "go"},
+		{"lower case or is a word", "go or rust", "go & or & rust"},
+		{"quoted or is a word", `go "OR" rust`, "go & or & rust"},
+		{"split words", "e-mail", "(e <-> mail)"},
+		{"operators dropped", "a&b | !c", "(a <-> b) & c"},
+		{"extra whitespace", "  go \t rust  ", "go & rust"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ToTSQuery(tt.input)
+			if err != nil {
+				t.Fatalf("ToTSQuery(%q): %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ToTSQuery(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToTSQueryNoWords(t *testing.T) {
+	for _, input := range []string{"", "   ", "OR", "-", `""`, "!&|", "-*"} {
+		if got, err := ToTSQuery(input); err == nil {
+			t.Errorf("ToTSQuery(%q) = %q, want an error", input, got)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package tui
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// openBrowser opens url in the default browser of the machine the reader
+// runs on. It fails over SSH, where that browser is not the user's.
+func openBrowser(url string) error {
+	if os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" {
+		return errors.New("running over SSH")
+	}
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			return errors.New("no graphical session")
+		}
+		cmd = exec.Command("xdg-open", url)
+	}
+
+	err := cmd.Start()
+	if err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
@@ -0,0 +1,106 @@
+package tui
+
+import (
+	"io"
+	"unicode/utf8"
+)
+
+// Names of the keys that are not a printable character.
+const (
+	keyUp       = "up"
+	keyDown     = "down"
+	keyLeft     = "left"
+	keyRight    = "right"
+	keyPageUp   = "pgup"
+	keyPageDown = "pgdn"
+	keyHome     = "home"
+	keyEnd      = "end"
+	keyEnter    = "enter"
+	keyTab      = "tab"
+	keyEscape   = "esc"
+	keyCtrlC    = "ctrl-c"
+)
+
+// escapeKeys maps the sequences terminals send for special keys, in both
+// normal and application cursor mode, to key names.
+var escapeKeys = map[string]string{
+	"\x1b[A": keyUp, "\x1bOA": keyUp,
+	"\x1b[B": keyDown, "\x1bOB": keyDown,
+	"\x1b[C": keyRight, "\x1bOC": keyRight,
+	"\x1b[D": keyLeft, "\x1bOD": keyLeft,
+	"\x1b[H": keyHome, "\x1bOH": keyHome, "\x1b[1~": keyHome, "\x1b[7~": keyHome,
+	"\x1b[F": keyEnd, "\x1bOF": keyEnd, "\x1b[4~": keyEnd, "\x1b[8~": keyEnd,
+	"\x1b[5~": keyPageUp,
+	"\x1b[6~": keyPageDown,
+}
+
+// readKeys sends the keys read from r until it fails. A read holds a key,
+// or several when they are pasted or arrive together over a slow link.
+func readKeys(r io.Reader, keys chan<- string) {
+	buf := make([]byte, 64)
+	for {
+		n, err := r.Read(buf)
+		if err != nil {
+			close(keys)
+			return
+		}
+		for _, key := range parseKeys(buf[:n]) {
+			keys <- key
+		}
+	}
+}
+
+func parseKeys(b []byte) []string {
+	var keys []string
+	for len(b) > 0 {
+		if b[0] == 0x1b {
+			key, n := parseEscape(b)
+			if key != "" {
+				keys = append(keys, key)
+			}
+			b = b[n:]
+			continue
+		}
+
+		switch b[0] {
+		case '\r', '\n':
+			keys = append(keys, keyEnter)
+		case '\t':
+			keys = append(keys, keyTab)
+		case 3:
+			keys = append(keys, keyCtrlC)
+		default:
+			r, n := utf8.DecodeRune(b)
+			if r != utf8.RuneError && r >= ' ' {
+				keys = append(keys, string(r))
+			}
+			b = b[n:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+// parseEscape returns the key of the escape sequence b starts with and its
+// length. Unknown sequences are skipped whole.
+func parseEscape(b []byte) (string, int) {
+	if len(b) < 3 || (b[1] != '[' && b[1] != 'O') {
+		return keyEscape, 1
+	}
+
+	// A CSI sequence ends with a byte in the range @ to ~.
+	n := 2
+	for n < len(b) && (b[n] < 0x40 || b[n] > 0x7e) {
+		n++
+	}
+	if n < len(b) {
+		n++
+	}
+
+	key, ok := escapeKeys[string(b[:n])]
+	if !ok {
+		return "", n
+	}
+	return key, n
+}
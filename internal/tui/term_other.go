@@ -0,0 +1,16 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package tui
+
+import (
+	"errors"
+	"os"
+)
+
+// resizeSignal is nil where terminals do not report size changes; the
+// reader then keeps the size it started with.
+var resizeSignal os.Signal
+
+func makeRaw(f *os.File) (func(), error) {
+	return nil, errors.New("the terminal reader is not supported on this system")
+}
@@ -0,0 +1,51 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package tui
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// resizeSignal is sent when the terminal changes size, including the
+// window of an SSH client.
+var resizeSignal os.Signal = syscall.SIGWINCH
+
+// makeRaw puts the terminal f into raw mode, so keys arrive one at a time
+// without echo, and returns a function restoring the previous mode.
+func makeRaw(f *os.File) (func(), error) {
+	fd := f.Fd()
+
+	var old syscall.Termios
+	err := termios(fd, ioctlGetTermios, &old)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := old
+	raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP | syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	raw.Oflag &^= syscall.OPOST
+	raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+	raw.Cflag |= syscall.CS8
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+
+	err = termios(fd, ioctlSetTermios, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		termios(fd, ioctlSetTermios, &old)
+	}, nil
+}
+
+func termios(fd uintptr, request uintptr, t *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(unsafe.Pointer(t)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+// Package tui is a full-screen terminal reader for the posts of the feeds a
+// user follows. It draws with plain ANSI escapes, which every terminal and
+// SSH client understands, and needs no terminfo database.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/chandanbsd/gator/internal/render"
+	"github.com/google/uuid"
+)
+
+// Feed is an entry of the feeds pane.
+type Feed struct {
+	Name string
+	URL  string
+}
+
+// Post is an entry of the posts pane.
+type Post struct {
+	ID          uuid.UUID
+	FeedName    string
+	Title       string
+	URL         string
+	Description string
+	Published   time.Time
+	Read        bool
+	Starred     bool
+}
+
+// Source is where the reader gets its feeds and posts and records what the
+// user does with them.
+type Source interface {
+	Feeds(ctx context.Context) ([]Feed, error)
+	// Posts returns the newest posts of the feed with feedURL, or of every
+	// followed feed when feedURL is empty.
+	Posts(ctx context.Context, feedURL string) ([]Post, error)
+	SetRead(ctx context.Context, id uuid.UUID, read bool) error
+	SetStarred(ctx context.Context, id uuid.UUID, starred bool) error
+}
+
+// Terminal control sequences.
+const (
+	enterAltScreen = "\x1b[?1049h"
+	exitAltScreen  = "\x1b[?1049l"
+	hideCursor     = "\x1b[?25l"
+	showCursor     = "\x1b[?25h"
+	cursorHome     = "\x1b[H"
+	clearLine      = "\x1b[K"
+	clearBelow     = "\x1b[J"
+)
+
+// Run shows the reader on the terminal of in and out until the user quits
+// or ctx is cancelled. title is shown in the top bar.
+func Run(ctx context.Context, in, out *os.File, title string, src Source) error {
+	if !render.IsTerminal(in) || !render.IsTerminal(out) {
+		return fmt.Errorf("the reader needs a terminal")
+	}
+
+	v := &view{ctx: ctx, src: src, title: title, out: out}
+	v.resize()
+	err := v.load()
+	if err != nil {
+		return err
+	}
+
+	restore, err := makeRaw(in)
+	if err != nil {
+		return fmt.Errorf("switching the terminal to raw mode: %w", err)
+	}
+	defer restore()
+
+	fmt.Fprint(out, enterAltScreen+hideCursor)
+	defer fmt.Fprint(out, showCursor+exitAltScreen)
+
+	resized := make(chan os.Signal, 1)
+	if resizeSignal != nil {
+		signal.Notify(resized, resizeSignal)
+		defer signal.Stop(resized)
+	}
+
+	keys := make(chan string)
+	go readKeys(in, keys)
+
+	for {
+		v.draw()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-resized:
+			v.resize()
+		case key, ok := <-keys:
+			if !ok {
+				return nil
+			}
+			if !v.handle(key) {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,421 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/chandanbsd/gator/internal/render"
+)
+
+const (
+	reverse = "\x1b[7m"
+	bold    = "\x1b[1m"
+	dim     = "\x1b[2m"
+	reset   = "\x1b[0m"
+)
+
+const (
+	// feedsPaneWidth is the width of the feeds pane, which is hidden on
+	// terminals narrower than minWidthForFeeds.
+	feedsPaneWidth   = 28
+	minWidthForFeeds = 70
+	// minHeight is the fewest rows the reader can be drawn in.
+	minHeight = 8
+)
+
+const helpLine = "tab pane  j/k next/prev  space scroll  m read  s star  o open  r refresh  q quit"
+
+type pane int
+
+const (
+	feedsPane pane = iota
+	postsPane
+)
+
+// view is the state of the reader and draws it.
+type view struct {
+	ctx   context.Context
+	src   Source
+	title string
+	out   *os.File
+
+	width, height int
+
+	// feeds starts with the entry for all feeds, which has no URL.
+	feeds   []Feed
+	posts   []Post
+	feed    int
+	post    int
+	feedTop int
+	postTop int
+	// previewTop is the first line of the preview that is shown.
+	previewTop int
+	focus      pane
+	status     string
+}
+
+func (v *view) resize() {
+	width, height, ok := render.Size(v.out)
+	if !ok {
+		width, height = 80, 24
+	}
+	v.width, v.height = width, max(height, minHeight)
+}
+
+// load reads the feeds and the posts of the selected feed, keeping the
+// selection where the same feed and post are still there.
+func (v *view) load() error {
+	feeds, err := v.src.Feeds(v.ctx)
+	if err != nil {
+		return err
+	}
+
+	selectedURL := ""
+	if v.feed < len(v.feeds) {
+		selectedURL = v.feeds[v.feed].URL
+	}
+	v.feeds = append([]Feed{{Name: "All feeds"}}, feeds...)
+	v.feed = 0
+	for i, feed := range v.feeds {
+		if feed.URL == selectedURL {
+			v.feed = i
+		}
+	}
+
+	return v.loadPosts()
+}
+
+func (v *view) loadPosts() error {
+	selected := v.selectedPost()
+
+	posts, err := v.src.Posts(v.ctx, v.feeds[v.feed].URL)
+	if err != nil {
+		return err
+	}
+	v.posts = posts
+
+	v.post = 0
+	for i, post := range posts {
+		if selected != nil && post.ID == selected.ID {
+			v.post = i
+		}
+	}
+	v.previewTop = 0
+	return nil
+}
+
+func (v *view) selectedPost() *Post {
+	if v.post < len(v.posts) {
+		return &v.posts[v.post]
+	}
+	return nil
+}
+
+// handle acts on key and reports whether the reader should keep running.
+func (v *view) handle(key string) bool {
+	v.status = ""
+
+	switch key {
+	case "q", keyCtrlC:
+		return false
+	case keyTab:
+		v.focus = 1 - v.focus
+	case "h", keyLeft:
+		v.focus = feedsPane
+	case "l", keyRight, keyEnter:
+		v.focus = postsPane
+	case "j", keyDown:
+		v.move(1)
+	case "k", keyUp:
+		v.move(-1)
+	case keyPageDown:
+		v.move(v.listHeight())
+	case keyPageUp:
+		v.move(-v.listHeight())
+	case keyHome, "g":
+		v.move(-len(v.posts) - len(v.feeds))
+	case keyEnd, "G":
+		v.move(len(v.posts) + len(v.feeds))
+	case " ":
+		v.previewTop += max(v.previewHeight()-1, 1)
+	case "b":
+		v.previewTop = max(v.previewTop-max(v.previewHeight()-1, 1), 0)
+	case "m":
+		v.toggleRead()
+	case "s":
+		v.toggleStarred()
+	case "o":
+		v.open()
+	case "r":
+		err := v.load()
+		if err != nil {
+			v.status = "Refresh failed: " + err.Error()
+		} else {
+			v.status = fmt.Sprintf("Refreshed, %d posts", len(v.posts))
+		}
+	}
+	return true
+}
+
+// move moves the selection of the focused pane by delta entries. Moving in
+// the feeds pane shows the posts of the newly selected feed.
+func (v *view) move(delta int) {
+	if v.focus == feedsPane {
+		next := clamp(v.feed+delta, 0, len(v.feeds)-1)
+		if next == v.feed {
+			return
+		}
+		v.feed = next
+		err := v.loadPosts()
+		if err != nil {
+			v.status = "Loading posts failed: " + err.Error()
+		}
+		return
+	}
+
+	next := clamp(v.post+delta, 0, len(v.posts)-1)
+	if next != v.post {
+		v.post = next
+		v.previewTop = 0
+	}
+}
+
+func (v *view) toggleRead() {
+	post := v.selectedPost()
+	if post == nil {
+		return
+	}
+	err := v.src.SetRead(v.ctx, post.ID, !post.Read)
+	if err != nil {
+		v.status = "Marking the post failed: " + err.Error()
+		return
+	}
+	post.Read = !post.Read
+}
+
+func (v *view) toggleStarred() {
+	post := v.selectedPost()
+	if post == nil {
+		return
+	}
+	err := v.src.SetStarred(v.ctx, post.ID, !post.Starred)
+	if err != nil {
+		v.status = "Starring the post failed: " + err.Error()
+		return
+	}
+	post.Starred = !post.Starred
+}
+
+func (v *view) open() {
+	post := v.selectedPost()
+	if post == nil {
+		return
+	}
+	err := openBrowser(post.URL)
+	if err != nil {
+		// Over SSH there is no browser to start, the link is shown instead
+		v.status = "Link: " + post.URL
+		return
+	}
+	v.status = "Opened " + post.URL
+}
+
+// Layout of the screen: a title bar, the panes and a status line. The
+// posts pane is split into the list of posts and the preview below it.
+
+func (v *view) feedsWidth() int {
+	if v.width < minWidthForFeeds {
+		return 0
+	}
+	return feedsPaneWidth
+}
+
+func (v *view) bodyHeight() int {
+	return v.height - 2
+}
+
+func (v *view) listHeight() int {
+	return max(v.bodyHeight()*2/5, 3)
+}
+
+func (v *view) previewHeight() int {
+	return v.bodyHeight() - v.listHeight() - 1
+}
+
+// draw writes the whole screen. Every line is overwritten in place, which
+// avoids flicker and keeps the output small over slow connections.
+func (v *view) draw() {
+	var b strings.Builder
+	b.WriteString(cursorHome)
+
+	unread := 0
+	for _, post := range v.posts {
+		if !post.Read {
+			unread++
+		}
+	}
+	top := fmt.Sprintf(" %s  %s: %d posts, %d unread", v.title, v.feeds[v.feed].Name, len(v.posts), unread)
+	b.WriteString(reverse + fit(top, v.width) + reset + "\r\n")
+
+	feedsWidth := v.feedsWidth()
+	rightWidth := v.width
+	if feedsWidth > 0 {
+		rightWidth -= feedsWidth + 1
+	}
+
+	feedLines := v.feedLines(feedsWidth)
+	rightLines := append(v.postLines(rightWidth), dim+strings.Repeat("─", rightWidth)+reset)
+	rightLines = append(rightLines, v.previewLines(rightWidth)...)
+
+	for i := range v.bodyHeight() {
+		if feedsWidth > 0 {
+			b.WriteString(feedLines[i] + dim + "│" + reset)
+		}
+		b.WriteString(rightLines[i] + clearLine + "\r\n")
+	}
+
+	status := v.status
+	if status == "" {
+		status = helpLine
+	}
+	b.WriteString(dim + fit(" "+status, v.width) + reset + clearBelow)
+
+	fmt.Fprint(v.out, b.String())
+}
+
+func (v *view) feedLines(width int) []string {
+	if width == 0 {
+		return nil
+	}
+	height := v.bodyHeight()
+	v.feedTop = scrollTo(v.feedTop, v.feed, height)
+
+	lines := make([]string, height)
+	for i := range lines {
+		n := v.feedTop + i
+		if n >= len(v.feeds) {
+			lines[i] = fit("", width)
+			continue
+		}
+		lines[i] = v.selection(feedsPane, n == v.feed, fit(" "+v.feeds[n].Name, width))
+	}
+	return lines
+}
+
+func (v *view) postLines(width int) []string {
+	height := v.listHeight()
+	v.postTop = scrollTo(v.postTop, v.post, height)
+
+	lines := make([]string, height)
+	for i := range lines {
+		n := v.postTop + i
+		if n >= len(v.posts) {
+			lines[i] = fit("", width)
+			if n == 0 {
+				lines[i] = fit(" No posts", width)
+			}
+			continue
+		}
+
+		post := v.posts[n]
+		marks := " ●"
+		if post.Read {
+			marks = "  "
+		}
+		if post.Starred {
+			marks += "★"
+		} else {
+			marks += " "
+		}
+		line := fmt.Sprintf("%s %s  %s  %s", marks, post.Published.Local().Format("Jan 02"), fit(post.FeedName, 16), post.Title)
+		line = fit(line, width)
+
+		if n == v.post {
+			lines[i] = v.selection(postsPane, true, line)
+		} else if post.Read {
+			lines[i] = dim + line + reset
+		} else {
+			lines[i] = line
+		}
+	}
+	return lines
+}
+
+func (v *view) previewLines(width int) []string {
+	height := v.previewHeight()
+	lines := make([]string, height)
+	for i := range lines {
+		lines[i] = fit("", width)
+	}
+
+	post := v.selectedPost()
+	if post == nil {
+		return lines
+	}
+
+	textWidth := width - 2
+	header := []string{
+		bold + fit(" "+post.Title, width) + reset,
+		fit(fmt.Sprintf(" %s · %s (%s)", post.FeedName, post.Published.Local().Format("Mon 2 Jan 2006 15:04"), render.Relative(post.Published, time.Now())), width),
+		dim + fit(" "+post.URL, width) + reset,
+		fit("", width),
+	}
+
+	var body []string
+	text := render.HTMLToText(post.Description)
+	if text != "" {
+		for _, line := range strings.Split(render.Wrap(text, textWidth, " "), "\n") {
+			body = append(body, fit(line, width))
+		}
+	}
+
+	v.previewTop = clamp(v.previewTop, 0, max(len(body)-max(height-len(header), 0), 0))
+	all := append(header, body[v.previewTop:]...)
+	copy(lines, all)
+	return lines
+}
+
+// selection styles line, the selected entry of pane when selected. The
+// selection of the focused pane stands out more than the other one.
+func (v *view) selection(p pane, selected bool, line string) string {
+	switch {
+	case !selected:
+		return line
+	case v.focus == p:
+		return reverse + line + reset
+	default:
+		return bold + line + reset
+	}
+}
+
+// fit pads or cuts s to exactly width characters.
+func fit(s string, width int) string {
+	n := utf8.RuneCountInString(s)
+	if n <= width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	if width <= 0 {
+		return ""
+	}
+	return string([]rune(s)[:width-1]) + "…"
+}
+
+// scrollTo returns the first entry to show so that selected is one of the
+// height entries shown, moving top as little as possible.
+func scrollTo(top, selected, height int) int {
+	if selected < top {
+		return selected
+	}
+	if selected >= top+height {
+		return selected - height + 1
+	}
+	return top
+}
+
+func clamp(n, low, high int) int {
+	return max(low, min(n, high))
+}
@@ -4,14 +4,18 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"flag"
 	"fmt"
+	"io/fs"
 	"os"
-	"strconv"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/chandanbsd/gator/internal/config"
 	"github.com/chandanbsd/gator/internal/database"
 	"github.com/chandanbsd/gator/internal/feed"
+	"github.com/chandanbsd/gator/internal/output"
 	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 )
@@ -19,44 +23,98 @@ import (
 type command struct {
 	Name      string
 	Arguments []string
+	// Flags holds the parsed flags declared by the command, read them with
+	// stringFlag, intFlag, boolFlag and durationFlag.
+	Flags *flag.FlagSet
+	// Output is the format listing commands print in, from --output.
+	Output output.Format
 }
 
 type commands struct {
-	options map[string]func(*state, command) error
+	options map[string]commandInfo
 }
 
+// commandInfo describes a registered command. run uses it to validate the
+// arguments before the handler is called and help renders it.
+type commandInfo struct {
+	Usage       string
+	Description string
+	MinArgs     int
+	// MaxArgs is the most arguments the command takes, or noMaxArgs.
+	MaxArgs       int
+	RequiresLogin bool
+	// Flags declares the flags of the command on fs, nil if it has none.
+	Flags func(fs *flag.FlagSet)
+
+	handler func(*state, command) error
+}
+
+const noMaxArgs = -1
+
 type state struct {
-	db   *database.Queries
+	// ctx is cancelled on SIGINT or SIGTERM.
+	ctx context.Context
+	db  *database.Queries
+	// conn is the connection pool behind db, for transactions.
+	conn *sql.DB
 	conf *config.Config
 }
 
-func (c *commands) run(s *state, cmd command) error {
-	for name, handler := range c.options {
-		if name == cmd.Name {
-			err := handler(s, cmd)
-			return err
-		}
+// run executes cmd and returns the process exit code. Errors are reported
+// on stderr, with the exit code telling their kind apart, see exitCode.
+func (c *commands) run(s *state, cmd command) int {
+	info, ok := c.options[cmd.Name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", invalidArgs("unknown command %q, run gator help for a list", cmd.Name))
+		return exitInvalidArgs
 	}
-	return errors.New("The command you have entered is not valid")
-}
 
-func (c *commands) register(name string, f func(*state, command) error) {
-	c.options[name] = f
-}
+	fs := info.newFlagSet(cmd.Name)
+	globalFlags(fs)
+	err := parseFlags(fs, &cmd)
+	if errors.Is(err, flag.ErrHelp) {
+		return c.run(s, command{Name: "help", Arguments: []string{cmd.Name}})
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", invalidArgs("%v, usage: gator %s", err, info.Usage))
+		return exitInvalidArgs
+	}
 
-func loginHandler(s *state, cmd command) error {
-	if s == nil {
-		fmt.Println("Please enter a command")
-		os.Exit(1)
+	cmd.Output, err = output.ParseFormat(cmd.stringFlag("output"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", invalidArgs("%v", err))
+		return exitInvalidArgs
+	}
+
+	if len(cmd.Arguments) < info.MinArgs || (info.MaxArgs != noMaxArgs && len(cmd.Arguments) > info.MaxArgs) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", invalidArgs("usage: gator %s", info.Usage))
+		return exitInvalidArgs
 	}
 
-	if cmd.Arguments == nil || len(cmd.Arguments) == 0 {
-		fmt.Println("Please provide a username")
-		os.Exit(1)
+	err = info.handler(s, cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitCode(err)
 	}
-	user, err := s.db.GetUser(context.Background(), cmd.Arguments[0])
+	return 0
+}
+
+func (c *commands) register(name string, info commandInfo, f func(*state, command) error) {
+	info.handler = f
+	c.options[name] = info
+}
+
+// registerLoggedIn registers a command that needs a current user, which is
+// looked up by middlewareLoggedIn and passed to the handler.
+func (c *commands) registerLoggedIn(name string, info commandInfo, f func(*state, command, database.User) error) {
+	info.RequiresLogin = true
+	c.register(name, info, middlewareLoggedIn(f))
+}
+
+func loginHandler(s *state, cmd command) error {
+	user, err := s.db.GetUser(s.ctx, cmd.Arguments[0])
 	if err != nil {
-		os.Exit(1)
+		return dbError(err, "user %s", cmd.Arguments[0])
 	}
 	s.conf.SetUser(user.Name)
 
@@ -66,24 +124,168 @@ func loginHandler(s *state, cmd command) error {
 	return nil
 }
 
-func registerHandlers(coms commands) {
-	coms.register("login", loginHandler)
-	coms.register("register", registerHandler)
-	coms.register("users", usersHandler)
-	coms.register("reset", deleteHandler)
-	coms.register("agg", aggHandler)
-	coms.register("feeds", feedsHandler)
-	coms.register("addfeed", middlewareLoggedIn(addFeedHandler))
-	coms.register("follow", middlewareLoggedIn(followHandler))
-	coms.register("following", middlewareLoggedIn(followingHandler))
-	coms.register("unfollow", middlewareLoggedIn(deleteFeedFollowHandler))
-	coms.register("browse", middlewareLoggedIn(browseHandler))
+func registerHandlers(coms *commands) {
+	coms.register("help", commandInfo{
+		Usage:       "help [command]",
+		Description: "Show the available commands, or the usage of one command",
+		MaxArgs:     1,
+	}, coms.helpHandler)
+	coms.register("login", commandInfo{
+		Usage:       "login <username>",
+		Description: "Switch the current user to an existing user",
+		MinArgs:     1,
+		MaxArgs:     1,
+	}, loginHandler)
+	coms.register("register", commandInfo{
+		Usage:       "register <username>",
+		Description: "Create a user and make it the current user",
+		MinArgs:     1,
+		MaxArgs:     1,
+	}, registerHandler)
+	coms.register("users", commandInfo{
+		Usage:       "users",
+		Description: "List all users",
+	}, usersHandler)
+	coms.register("reset", commandInfo{
+		Usage:       "reset",
+		Description: "Delete all users along with their feeds and follows",
+	}, deleteHandler)
+	coms.register("agg", commandInfo{
+		Usage:       "agg [--interval 1m] [--workers n] [--disable-after n] [--connect-timeout 10s] [--read-timeout 30s] [--fetch-timeout 1m] [--max-feed-mb n]",
+		Description: "Keep fetching due feeds, checking at most every interval",
+		MaxArgs:     2,
+		Flags: func(fs *flag.FlagSet) {
+			fs.Duration("interval", 0, "shortest time between two fetches of a feed, can also be given as the first argument")
+			fetchFlags(fs)
+		},
+	}, aggHandler)
+	coms.register("serve", commandInfo{
+		Usage:       "serve [--addr host:port] [--interval 10m] [--workers n] [--disable-after n] [--connect-timeout 10s] [--read-timeout 30s] [--fetch-timeout 1m] [--max-feed-mb n]",
+		Description: "Serve a JSON API over HTTP while collecting feeds like agg",
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("addr", defaultServeAddr, "`address` to listen on, e.g. :8080 for every interface")
+			fs.Duration("interval", defaultServeInterval, "shortest time between two fetches of a feed, 0 serves without collecting")
+			fetchFlags(fs)
+		},
+	}, serveHandler)
+	coms.register("feeds", commandInfo{
+		Usage:       "feeds",
+		Description: "List all feeds and who added them",
+	}, feedsHandler)
+	coms.register("feed", commandInfo{
+		Usage:       "feed health [--stale 72h] [--slow 5s] [--all] | feed enable <url> | feed disable <url>",
+		Description: "List feeds that keep failing, have not been fetched for a while or are slow to fetch, or turn fetching a feed back on or off",
+		MinArgs:     1,
+		MaxArgs:     2,
+		Flags: func(fs *flag.FlagSet) {
+			fs.Duration("stale", defaultStaleAfter, "report feeds not fetched successfully for this `duration`")
+			fs.Duration("slow", defaultSlowAfter, "report feeds whose recent fetches take this `duration` on average")
+			fs.Bool("all", false, "list every feed with its health")
+		},
+	}, feedHandler)
+	coms.registerLoggedIn("addfeed", commandInfo{
+		Usage:       "addfeed <name> <url> [--no-follow]",
+		Description: "Add a feed and follow it",
+		MinArgs:     2,
+		MaxArgs:     2,
+		Flags: func(fs *flag.FlagSet) {
+			fs.Bool("no-follow", false, "add the feed without following it")
+		},
+	}, addFeedHandler)
+	coms.registerLoggedIn("follow", commandInfo{
+		Usage:       "follow <url>",
+		Description: "Follow an existing feed",
+		MinArgs:     1,
+		MaxArgs:     1,
+	}, followHandler)
+	coms.registerLoggedIn("following", commandInfo{
+		Usage:       "following",
+		Description: "List the feeds you follow",
+	}, followingHandler)
+	coms.registerLoggedIn("unfollow", commandInfo{
+		Usage:       "unfollow <url>",
+		Description: "Stop following a feed",
+		MinArgs:     1,
+		MaxArgs:     1,
+	}, deleteFeedFollowHandler)
+	coms.registerLoggedIn("import", commandInfo{
+		Usage:       "import <file.opml>",
+		Description: "Add and follow every feed listed in an OPML file",
+		MinArgs:     1,
+		MaxArgs:     1,
+	}, importHandler)
+	coms.registerLoggedIn("export", commandInfo{
+		Usage:       "export [--format opml] [--file path]",
+		Description: "Write the feeds you follow as an OPML document",
+		Flags: func(fs *flag.FlagSet) {
+			fs.String("format", "opml", "export format, only opml is supported")
+			fs.String("file", "", "write to this `path` instead of stdout")
+		},
+	}, exportHandler)
+	coms.registerLoggedIn("browse", commandInfo{
+		Usage:       "browse [--limit n] [--feed url] [--since 24h] [--unread | --all] [--length n] [--after cursor | --before cursor] [--pager]",
+		Description: "Show the newest unread posts from the feeds you follow",
+		MaxArgs:     1,
+		Flags: func(fs *flag.FlagSet) {
+			fs.Int("limit", defaultBrowseLimit, "number of posts to show, can also be given as the first argument")
+			fs.String("feed", "", "only show posts from the feed with this `url`")
+			fs.Duration("since", 0, "only show posts published within this `duration`, e.g. 24h")
+			fs.Bool("unread", false, "only show posts that are not read yet, the default")
+			fs.Bool("all", false, "include posts that are already read")
+			fs.Int("length", defaultDescriptionLength, "cap descriptions at this many characters, 0 shows them in full")
+			fs.String("after", "", "show the page after this `cursor`, as printed at the end of a page")
+			fs.String("before", "", "show the page before this `cursor`, as printed at the end of a page")
+			fs.Bool("pager", false, fmt.Sprintf("show one page at a time, %d posts unless --limit is given", defaultPagerLimit))
+		},
+	}, browseHandler)
+	coms.registerLoggedIn("tui", commandInfo{
+		Usage:       "tui",
+		Description: "Read posts in a full-screen terminal reader, press q to quit",
+	}, tuiHandler)
+	coms.registerLoggedIn("search", commandInfo{
+		Usage:       "search <query> [--limit n] [--all-feeds]",
+		Description: `Search stored posts, best matches first. Use "quotes" for phrases, word* for prefixes, -word to exclude and OR for either`,
+		MinArgs:     1,
+		MaxArgs:     noMaxArgs,
+		Flags: func(fs *flag.FlagSet) {
+			fs.Int("limit", defaultSearchLimit, "number of results to show")
+			fs.Bool("all-feeds", false, "also search feeds you do not follow")
+		},
+	}, searchHandler)
+	coms.registerLoggedIn("read", commandInfo{
+		Usage:       "read [post-id] [--feed url] [--before date]",
+		Description: "Mark a post, or all posts of a feed or older than a date, as read",
+		MaxArgs:     1,
+		Flags:       markPostsFlags,
+	}, readHandler)
+	coms.registerLoggedIn("unread", commandInfo{
+		Usage:       "unread [post-id] [--feed url] [--before date]",
+		Description: "Mark a post, or all posts of a feed or older than a date, as unread",
+		MaxArgs:     1,
+		Flags:       markPostsFlags,
+	}, unreadHandler)
+	coms.registerLoggedIn("star", commandInfo{
+		Usage:       "star <post-id>",
+		Description: "Save a post to your starred posts",
+		MinArgs:     1,
+		MaxArgs:     1,
+	}, starHandler)
+	coms.registerLoggedIn("unstar", commandInfo{
+		Usage:       "unstar <post-id>",
+		Description: "Remove a post from your starred posts",
+		MinArgs:     1,
+		MaxArgs:     1,
+	}, unstarHandler)
+	coms.registerLoggedIn("starred", commandInfo{
+		Usage:       "starred",
+		Description: "List your starred posts with their feed and date",
+	}, starredHandler)
 }
 
 func deleteHandler(s *state, cmd command) error {
-	err := s.db.DeleteUsers(context.Background())
+	err := s.db.DeleteUsers(s.ctx)
 	if err != nil {
-		os.Exit(1)
+		return dbError(err, "deleting users")
 	}
 
 	s.conf.SetUser("")
@@ -92,14 +294,12 @@ func deleteHandler(s *state, cmd command) error {
 }
 
 func registerHandler(s *state, cmd command) error {
-	if len(cmd.Arguments) == 0 {
-		fmt.Println("Missing name argument")
-		os.Exit(1)
-	}
-
-	_, err := s.db.GetUser(context.Background(), cmd.Arguments[0])
+	_, err := s.db.GetUser(s.ctx, cmd.Arguments[0])
 	if err == nil {
-		os.Exit(1)
+		return fmt.Errorf("user %s: %w", cmd.Arguments[0], errAlreadyExists)
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return dbError(err, "user %s", cmd.Arguments[0])
 	}
 
 	currentTime := sql.NullTime{
@@ -118,10 +318,9 @@ func registerHandler(s *state, cmd command) error {
 		UpdatedAt: currentNullTime,
 		Name:      cmd.Arguments[0],
 	}
-	_, err = s.db.CreateUser(context.Background(), newUserParams)
+	_, err = s.db.CreateUser(s.ctx, newUserParams)
 	if err != nil {
-		fmt.Println("Failed to create the user.")
-		os.Exit(1)
+		return dbError(err, "creating user %s", cmd.Arguments[0])
 	}
 	s.conf.SetUser(cmd.Arguments[0])
 
@@ -130,83 +329,39 @@ func registerHandler(s *state, cmd command) error {
 }
 
 func usersHandler(s *state, cmd command) error {
-	users, err := s.db.GetUsers(context.Background())
+	users, err := s.db.GetUsers(s.ctx)
 	if err != nil {
-		os.Exit(1)
+		return dbError(err, "listing users")
 	}
 
+	records := make([]output.Record, 0, len(users))
 	for _, user := range users {
-
-		if user.Name == s.conf.CurrentUserName {
-			fmt.Println(user.Name + " (current)")
-		} else {
-			fmt.Println(user.Name)
-		}
+		records = append(records, userRecord(user, user.Name == s.conf.CurrentUserName))
 	}
-
-	return nil
+	return printRecords(cmd, userRecord(database.User{}, false), records)
 }
 
-func aggHandler(s *state, cmd command) error {
-
-	if len(cmd.Arguments) != 1 {
-		fmt.Println("Incorrect arguments list")
-		os.Exit(1)
-	}
-
-	duration, err := time.ParseDuration(cmd.Arguments[0])
-	if err != nil {
-		fmt.Println("Unable to parse duration")
-		return err	
-	}
-
-	ticker := time.NewTicker(duration)
-
-	for ; ; <-ticker.C {
-		scrapeFeeds(s)
-	}
+// fetchFlags declares the flags agg and serve collect feeds with, see
+// parseFetchFlags.
+func fetchFlags(fs *flag.FlagSet) {
+	fs.Int("workers", defaultAggWorkers, "number of feeds fetched at the same time")
+	fs.Int("disable-after", defaultDisableAfter, "disable a feed after this many failed fetches in a row, 0 never does")
+	fs.Duration("connect-timeout", feed.DefaultConnectTimeout, "give up on a feed whose server takes longer than this `duration` to connect to")
+	fs.Duration("read-timeout", feed.DefaultReadTimeout, "give up on a feed whose server stays silent for this `duration`")
+	fs.Duration("fetch-timeout", feed.DefaultTimeout, "give up on a feed that takes longer than this `duration` to download")
+	fs.Int("max-feed-mb", feed.DefaultMaxBodySize>>20, "give up on a feed larger than this many `megabytes`")
 }
 
-func browseHandler(s *state, cmd command, u database.User) error {
-	limit32 := int32(2)
-	if len(cmd.Arguments) == 1 {
-		limit64, err := strconv.ParseInt(cmd.Arguments[0], 10, 32)
-		if err != nil {
-			return err
-		}
-		limit32 = int32(limit64)
-	}
-
-	posts, err := s.db.GetPostsForUser(
-		context.Background(), 
-		database.GetPostsForUserParams{
-			UserID: u.ID,
-			Limit: int32(limit32),
-		})
-	if err != nil {
-		fmt.Println("Unable to get posts for user")
-		return err
-	}
-
-	for _, post := range posts {
-		fmt.Printf("Title: %v\tCreatedAt: %v\tUpdatedAt: %v\tTitle:%v\tUrl:%v\tDescription:%v\tPublishedAt:%v",
-			post.CreatedAt,
-			post.UpdatedAt,
-			post.Title,
-			post.Url,
-			post.Description,
-			post.PublishedAt,
-		)
-	}
-	return nil
+func markPostsFlags(fs *flag.FlagSet) {
+	fs.String("feed", "", "only posts from the feed with this `url`")
+	fs.String("before", "", "only posts published before this `date`, or this long ago, e.g. 2024-01-31 or 168h")
 }
 
 func middlewareLoggedIn(handler func(s *state, cmd command, user database.User) error) func(*state, command) error {
 	return func (s *state, cmd command) error{
-		user, err := s.db.GetUser(context.Background(), s.conf.CurrentUserName)
+		user, err := s.db.GetUser(s.ctx, s.conf.CurrentUserName)
 		if err != nil {
-			fmt.Println("Failed to get the current user")
-			return err
+			return dbError(err, "current user %q", s.conf.CurrentUserName)
 		}
 		return handler(s, cmd, user)
 	}
@@ -214,10 +369,6 @@ func middlewareLoggedIn(handler func(s *state, cmd command, user database.User)
 }
 
 func addFeedHandler(s *state, cmd command, user database.User) error {
-	if len(cmd.Arguments) < 2 {
-		fmt.Println("Missing name argument")
-		os.Exit(1)
-	}
 	currentTime := sql.NullTime{
 		Time:  time.Now(),
 		Valid: true,
@@ -239,40 +390,36 @@ func addFeedHandler(s *state, cmd command, user database.User) error {
 		UserID: user.ID,
 	}
 
-	_, err := s.db.CreateFeed(context.Background(), newFeed)
+	_, err := s.db.CreateFeed(s.ctx, newFeed)
 	if err != nil {
-		fmt.Println("Failed to create the feed")
-		os.Exit(1)
-	} else {
-		fmt.Println("User created successfully")
+		return dbError(err, "creating feed %s", cmd.Arguments[1])
+	}
+	fmt.Println("Feed created successfully")
+
+	if cmd.boolFlag("no-follow") {
+		return nil
 	}
 
-	followHandler(s, command{
+	return followHandler(s, command{
 		Name: "follow",
 		Arguments: []string{cmd.Arguments[1]},
 	}, user)
-	return nil
 }
 
 func feedsHandler(s *state, cmd command) error {
-	feeds, err := s.db.GetFeeds(context.Background())
+	feeds, err := s.db.GetFeeds(s.ctx)
 	if err != nil {
-		fmt.Println("Unable to fetch feeds")
-		os.Exit(1)
+		return dbError(err, "listing feeds")
 	}
 
+	records := make([]output.Record, 0, len(feeds))
 	for _, feed := range feeds {
-		fmt.Printf("name: %v url: %v, user_name: %v\n", feed.Name, feed.Url, feed.UserName)
+		records = append(records, feedRecord(feed))
 	}
-	return nil
+	return printRecords(cmd, feedRecord(database.GetFeedsRow{}), records)
 }
 
 func followHandler(s *state, cmd command, user database.User) error {
-	if len(cmd.Arguments) != 1 {
-		fmt.Println("Missing url or wrong arguments provided");
-		os.Exit(1)
-	}
-
 	currentTime := sql.NullTime{
 		Time:  time.Now(),
 		Valid: true,
@@ -283,10 +430,9 @@ func followHandler(s *state, cmd command, user database.User) error {
 		Valid: false,
 	}
 
-	feed, err := s.db.GetFeedByUrl(context.Background(), cmd.Arguments[0])
+	feed, err := s.db.GetFeedByUrl(s.ctx, cmd.Arguments[0])
 	if err != nil {
-		fmt.Println("Feed does not exist")
-		os.Exit(1)
+		return dbError(err, "feed %s", cmd.Arguments[0])
 	}
 
 	createdFeedFollowParam := database.CreateFeedFollowParams {
@@ -297,10 +443,9 @@ func followHandler(s *state, cmd command, user database.User) error {
 		UserID: user.ID,
 	}
 
-	createdFeedFollow, err := s.db.CreateFeedFollow(context.Background(), createdFeedFollowParam)
+	createdFeedFollow, err := s.db.CreateFeedFollow(s.ctx, createdFeedFollowParam)
 	if err != nil {
-		fmt.Println("Failed to folow the feed")
-		os.Exit(1)
+		return dbError(err, "following feed %s", cmd.Arguments[0])
 	}
 
 	fmt.Printf("Feed name: %s and current user: %s\n", createdFeedFollow.FeedName, createdFeedFollow.UserName)
@@ -310,22 +455,19 @@ func followHandler(s *state, cmd command, user database.User) error {
 
 func followingHandler(s *state, cmd command,  user database.User) error {
 
-	feedsForUser, err := s.db.GetFeedFollowsForUser(context.Background(), user.ID)
+	feedsForUser, err := s.db.GetFeedFollowsForUser(s.ctx, user.ID)
 	if err != nil {
-		fmt.Println("Failed to fetch feeds followed by user");
-		os.Exit(1)
+		return dbError(err, "getting feeds followed by %s", user.Name)
 	}
 
-	for _, feed := range feedsForUser {
-		fmt.Println(feed)
+	records := make([]output.Record, 0, len(feedsForUser))
+	for _, follow := range feedsForUser {
+		records = append(records, feedFollowRecord(follow))
 	}
-	return nil
+	return printRecords(cmd, feedFollowRecord(database.GetFeedFollowsForUserRow{}), records)
 }
 
 func deleteFeedFollowHandler(s *state, cmd command, user database.User) error {
-	if len(cmd.Arguments) != 1 {
-		fmt.Println("Missing url argument");
-	}
 	
 	feedFollowParams := database.DeleteFeedFollowParams {
 		UserID: user.ID,
@@ -333,119 +475,76 @@ func deleteFeedFollowHandler(s *state, cmd command, user database.User) error {
 	}
 
 
-	err := s.db.DeleteFeedFollow(context.Background(), feedFollowParams)
+	count, err := s.db.DeleteFeedFollow(s.ctx, feedFollowParams)
 	if err != nil {
-		fmt.Println("Failed to delete the feed follow")
-		os.Exit(1)
+		return dbError(err, "unfollowing feed %s", cmd.Arguments[0])
+	}
+	if count == 0 {
+		return fmt.Errorf("feed %s in the feeds you follow: %w", cmd.Arguments[0], errNotFound)
 	}
 	return nil
 }
 
-func scrapeFeeds(s *state) error{
-	fmt.Println("*************Fetching Feed**************")
-	nextFeed, err := s.db.GetNextFeedToFetch(context.Background())
-	if err != nil {
-		fmt.Println("Failed to fetch the next feed")
-		os.Exit(1)
-	}
-
-	markFeedFetchedParams := database.MarkFeedFetchedParams {
-		LastFetchedAt: sql.NullTime{
-			Time: time.Now(),
-		},
-		ID: nextFeed.ID,
-	}
+func main() {
+	s := state{}
 
-	rssFeed, err := feed.FetchFeed(context.Background(), nextFeed.Url)
-	if err != nil {
-		fmt.Println("Failed to fetch feed")
-		return err
+	coms := commands{
+		options: make(map[string]commandInfo),
 	}
-	
-	for _, item := range rssFeed.Channel.Item {
-
-	
-		currentNullTime := sql.NullTime{
-			Time:  time.Now(),
-			Valid: false,
-		}
-
-		pubAt, err :=time.Parse(time.RFC1123Z, item.PubDate)
-		if err != nil {
-			fmt.Print("Failed to parse time %v", err)
-			return err
-		}
-
-		newPost := database.CreatePostParams {
-			ID: uuid.New(),
-			CreatedAt: time.Now(),
-			UpdatedAt: currentNullTime,
-			Title: item.Title,
-			Url: item.Link,
-			Description: sql.NullString{
-				String: item.Description ,
-			},
-			PublishedAt: sql.NullTime{
-				Time: pubAt,
-			},
-			FeedID: nextFeed.ID,
-		}
+	registerHandlers(&coms)
 
-	err =	s.db.CreatePost(context.Background(), newPost)
-	fmt.Println("Failed to create post")
+	name, arguments, err := splitGlobalFlags(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(coms.run(&s, command{Name: "help"}))
 	}
-
-	err = s.db.MarkFeedFetched(context.Background(), markFeedFetchedParams)
 	if err != nil {
-		fmt.Println("Failed to mark the feed as fetched")
+		fmt.Fprintf(os.Stderr, "Error: %v\n", invalidArgs("%v, run gator help for the options", err))
+		os.Exit(exitInvalidArgs)
 	}
-
-	fmt.Println(rssFeed)
-	
-	return nil
-}
-
-
-func main() {
-	arguments := os.Args
-	s := state{}
-
-	if len(arguments) < 2 {
-		fmt.Println("You have not provided arguments")
-		os.Exit(1)
+	if name == "" {
+		coms.printHelp(os.Stderr)
+		os.Exit(exitInvalidArgs)
 	}
 
 	com := command{
-		Name:      arguments[1],
-		Arguments: arguments[2:],
+		Name:      name,
+		Arguments: arguments,
+	}
+
+	// help only reads the registry, so it works without a config file
+	if com.Name == "help" {
+		os.Exit(coms.run(&s, com))
 	}
 
 	c, err := config.Read()
+	if errors.Is(err, fs.ErrNotExist) {
+		err = fmt.Errorf("%w: %w", errNotFound, err)
+	}
 	if err != nil {
-		fmt.Println("Failed to read the config file")
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "Error: reading the config file: %v\n", err)
+		os.Exit(exitCode(err))
 	}
 
 	s.conf = &c
 
-	coms := commands{
-		options: make(map[string]func(*state, command) error),
-	}
-	registerHandlers(coms)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	s.ctx = ctx
+	// Restore the default handlers after the first signal, so a second
+	// one kills gator instead of waiting out the shutdown.
+	context.AfterFunc(ctx, stop)
 
 	db, err := sql.Open("postgres", s.conf.DbUrl)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", dbError(err, "opening database"))
+		os.Exit(exitDatabase)
+	}
 
+	s.conn = db
 	s.db = database.New(db)
 
-	err = coms.run(&s, com)
-	if err != nil {
-		fmt.Println("Error occured")
-		os.Exit(1)
-	}
+	code := coms.run(&s, com)
 
-	c, err = config.Read()
-	if err != nil {
-		fmt.Println("Failed to update the config")
-		os.Exit(1)
-	}
+	db.Close()
+	stop()
+	os.Exit(code)
 }
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/chandanbsd/gator/internal/database"
+	"github.com/chandanbsd/gator/internal/opml"
+	"github.com/google/uuid"
+)
+
+// Outcomes of importing a single OPML subscription.
+const (
+	importAdded    = "added"
+	importFollowed = "followed"
+	importPresent  = "already following"
+	importInvalid  = "invalid"
+	importFailed   = "failed"
+)
+
+func importHandler(s *state, cmd command, user database.User) error {
+	file, err := os.Open(cmd.Arguments[0])
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s: %w", cmd.Arguments[0], errNotFound)
+	}
+	if err != nil {
+		return invalidArgs("cannot open %s: %v", cmd.Arguments[0], err)
+	}
+	defer file.Close()
+
+	doc, err := opml.Parse(file)
+	if err != nil {
+		return invalidArgs("%s is not a valid OPML file: %v", cmd.Arguments[0], err)
+	}
+
+	subscriptions := doc.Subscriptions()
+	if len(subscriptions) == 0 {
+		fmt.Println("No feeds found")
+		return nil
+	}
+
+	counts := make(map[string]int)
+	var lastErr error
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, subscription := range subscriptions {
+		outcome, err := importSubscription(s, user, subscription)
+		counts[outcome]++
+
+		detail := subscription.XMLURL
+		if err != nil {
+			detail += " (" + err.Error() + ")"
+			if outcome == importFailed {
+				lastErr = err
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", outcome, subscription.Title, detail)
+	}
+	tw.Flush()
+
+	fmt.Printf("\n%d added, %d followed, %d already following, %d invalid, %d failed\n",
+		counts[importAdded],
+		counts[importFollowed],
+		counts[importPresent],
+		counts[importInvalid],
+		counts[importFailed],
+	)
+
+	return lastErr
+}
+
+// importSubscription creates the feed of subscription if it does not exist
+// yet and follows it for user. It returns one of the import outcomes and,
+// for invalid and failed ones, the reason.
+func importSubscription(s *state, user database.User, subscription opml.Subscription) (string, error) {
+	feedURL, err := url.Parse(subscription.XMLURL)
+	if err != nil || (feedURL.Scheme != "http" && feedURL.Scheme != "https") || feedURL.Host == "" {
+		return importInvalid, errors.New("not an http(s) url")
+	}
+
+	currentTime := sql.NullTime{
+		Time:  time.Now(),
+		Valid: true,
+	}
+
+	outcome := importFollowed
+
+	var feedID uuid.UUID
+	existing, err := s.db.GetFeedByUrl(s.ctx, subscription.XMLURL)
+	switch {
+	case err == nil:
+		feedID = existing.ID
+	case errors.Is(err, sql.ErrNoRows):
+		name := subscription.Title
+		if name == "" {
+			name = subscription.XMLURL
+		}
+
+		created, err := s.db.CreateFeed(s.ctx, database.CreateFeedParams{
+			ID:        uuid.New(),
+			CreatedAt: currentTime,
+			Name:      name,
+			Url:       subscription.XMLURL,
+			UserID:    user.ID,
+			SiteUrl: sql.NullString{
+				String: subscription.HTMLURL,
+				Valid:  subscription.HTMLURL != "",
+			},
+		})
+		if err != nil {
+			return importFailed, dbError(err, "creating feed %s", subscription.XMLURL)
+		}
+		feedID = created.ID
+		outcome = importAdded
+	default:
+		return importFailed, dbError(err, "feed %s", subscription.XMLURL)
+	}
+
+	following, err := s.db.IsFollowingFeed(s.ctx, database.IsFollowingFeedParams{
+		UserID: user.ID,
+		FeedID: feedID,
+	})
+	if err != nil {
+		return importFailed, dbError(err, "feed %s", subscription.XMLURL)
+	}
+	if following {
+		return importPresent, nil
+	}
+
+	_, err = s.db.CreateFeedFollow(s.ctx, database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: currentTime,
+		FeedID:    feedID,
+		UserID:    user.ID,
+		Folder: sql.NullString{
+			String: subscription.Folder,
+			Valid:  subscription.Folder != "",
+		},
+	})
+	if err != nil {
+		return importFailed, dbError(err, "following feed %s", subscription.XMLURL)
+	}
+
+	return outcome, nil
+}
+
+func exportHandler(s *state, cmd command, user database.User) error {
+	if format := cmd.stringFlag("format"); format != "opml" {
+		return invalidArgs("unsupported export format %q", format)
+	}
+
+	feeds, err := s.db.GetFeedFollowsForExport(s.ctx, user.ID)
+	if err != nil {
+		return dbError(err, "getting feeds followed by %s", user.Name)
+	}
+
+	subscriptions := make([]opml.Subscription, 0, len(feeds))
+	for _, feed := range feeds {
+		subscriptions = append(subscriptions, opml.Subscription{
+			Title:   feed.Name,
+			XMLURL:  feed.Url,
+			HTMLURL: feed.SiteUrl.String,
+			Folder:  feed.Folder.String,
+		})
+	}
+
+	doc := opml.New(fmt.Sprintf("gator subscriptions of %s", user.Name), subscriptions)
+
+	path := cmd.stringFlag("file")
+	if path == "" {
+		return doc.Write(os.Stdout)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", path, err)
+	}
+	defer file.Close()
+
+	err = doc.Write(file)
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+
+	fmt.Printf("Exported %d feeds to %s\n", len(subscriptions), path)
+	return file.Close()
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/chandanbsd/gator/internal/database"
+	"github.com/chandanbsd/gator/internal/feed"
+	"github.com/google/uuid"
+)
+
+func readHandler(s *state, cmd command, user database.User) error {
+	return markPosts(s, cmd, user, true)
+}
+
+func unreadHandler(s *state, cmd command, user database.User) error {
+	return markPosts(s, cmd, user, false)
+}
+
+// markPosts marks either the single post given as argument or every post
+// matching the --feed and --before flags as read or unread.
+func markPosts(s *state, cmd command, user database.User, read bool) error {
+	feedURL := cmd.stringFlag("feed")
+	before := cmd.stringFlag("before")
+
+	status := "unread"
+	if read {
+		status = "read"
+	}
+
+	if len(cmd.Arguments) == 1 {
+		if feedURL != "" || before != "" {
+			return invalidArgs("give either a post id or --feed/--before, not both")
+		}
+		return markPost(s, cmd.Arguments[0], user, read, status)
+	}
+
+	if feedURL == "" && before == "" {
+		return invalidArgs("give a post id, --feed or --before")
+	}
+
+	feedFilter := sql.NullString{String: feedURL, Valid: feedURL != ""}
+	beforeFilter := sql.NullTime{}
+	if before != "" {
+		t, err := parseBefore(before)
+		if err != nil {
+			return invalidArgs("%q is neither a date nor a duration", before)
+		}
+		beforeFilter = sql.NullTime{Time: t, Valid: true}
+	}
+
+	var count int64
+	var err error
+	if read {
+		count, err = s.db.MarkPostsRead(s.ctx, database.MarkPostsReadParams{
+			UserID:  user.ID,
+			ReadAt:  time.Now(),
+			FeedUrl: feedFilter,
+			Before:  beforeFilter,
+		})
+	} else {
+		count, err = s.db.MarkPostsUnread(s.ctx, database.MarkPostsUnreadParams{
+			UserID:  user.ID,
+			FeedUrl: feedFilter,
+			Before:  beforeFilter,
+		})
+	}
+	if err != nil {
+		return dbError(err, "marking posts %s", status)
+	}
+
+	fmt.Printf("Marked %d posts %s\n", count, status)
+	return nil
+}
+
+func markPost(s *state, id string, user database.User, read bool, status string) error {
+	postID, err := resolvePostID(s, id, user)
+	if err != nil {
+		return err
+	}
+
+	err = checkPostFollowed(s.ctx, s, user, postID)
+	if err != nil {
+		return err
+	}
+
+	var count int64
+	if read {
+		count, err = s.db.MarkPostRead(s.ctx, database.MarkPostReadParams{
+			UserID: user.ID,
+			PostID: postID,
+			ReadAt: time.Now(),
+		})
+	} else {
+		count, err = s.db.MarkPostUnread(s.ctx, database.MarkPostUnreadParams{
+			UserID: user.ID,
+			PostID: postID,
+		})
+	}
+	if err != nil {
+		return dbError(err, "marking post %s %s", shortID(postID), status)
+	}
+
+	if count == 0 {
+		fmt.Printf("Post %s was already %s\n", shortID(postID), status)
+		return nil
+	}
+	fmt.Printf("Marked post %s %s\n", shortID(postID), status)
+	return nil
+}
+
+// checkPostFollowed returns errNotFound unless postID is a post of a feed
+// user follows, the only posts read state is kept for.
+func checkPostFollowed(ctx context.Context, s *state, user database.User, postID uuid.UUID) error {
+	followed, err := s.db.IsPostFollowed(ctx, database.IsPostFollowedParams{
+		ID:     postID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return dbError(err, "looking up post %s", shortID(postID))
+	}
+	if !followed {
+		return fmt.Errorf("post %s in the feeds you follow: %w", shortID(postID), errNotFound)
+	}
+	return nil
+}
+
+// parseBefore accepts either a date, in any layout feed.ParseDate knows, or
+// a duration meaning that long ago, e.g. 168h for a week.
+func parseBefore(value string) (time.Time, error) {
+	d, err := time.ParseDuration(value)
+	if err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return feed.ParseDate(value)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+
+	"github.com/chandanbsd/gator/internal/database"
+	"github.com/chandanbsd/gator/internal/output"
+)
+
+// The records listing commands print with --output. Field names follow the
+// columns of the database rows they come from and must not change, scripts
+// depend on them.
+
+// printRecords writes records to stdout in the format of cmd. empty is a
+// record built from a zero row, it provides the header.
+func printRecords(cmd command, empty output.Record, records []output.Record) error {
+	return output.Write(os.Stdout, cmd.Output, output.Header(empty), records)
+}
+
+func userRecord(user database.User, current bool) output.Record {
+	return output.Record{
+		{Name: "id", Value: user.ID},
+		{Name: "name", Value: user.Name},
+		{Name: "created_at", Value: user.CreatedAt},
+		{Name: "updated_at", Value: user.UpdatedAt},
+		{Name: "current", Value: current},
+	}
+}
+
+func feedRecord(feed database.GetFeedsRow) output.Record {
+	return output.Record{
+		{Name: "name", Value: feed.Name},
+		{Name: "url", Value: feed.Url},
+		{Name: "user_name", Value: feed.UserName},
+	}
+}
+
+func feedFollowRecord(follow database.GetFeedFollowsForUserRow) output.Record {
+	return output.Record{
+		{Name: "feed_name", Value: follow.FeedName},
+		{Name: "feed_url", Value: follow.FeedUrl},
+		{Name: "folder", Value: follow.Folder},
+		{Name: "created_at", Value: follow.CreatedAt},
+	}
+}
+
+func postRecord(post database.GetPostsForUserRow) output.Record {
+	return output.Record{
+		{Name: "id", Value: post.ID},
+		{Name: "created_at", Value: post.CreatedAt},
+		{Name: "updated_at", Value: post.UpdatedAt},
+		{Name: "title", Value: post.Title},
+		{Name: "url", Value: post.Url},
+		{Name: "description", Value: post.Description},
+		{Name: "published_at", Value: post.PublishedAt},
+		{Name: "feed_id", Value: post.FeedID},
+		{Name: "feed_name", Value: post.FeedName},
+		{Name: "read", Value: post.Read},
+	}
+}
+
+func starredPostRecord(post database.GetStarredPostsForUserRow) output.Record {
+	return output.Record{
+		{Name: "id", Value: post.ID},
+		{Name: "title", Value: post.Title},
+		{Name: "url", Value: post.Url},
+		{Name: "published_at", Value: post.PublishedAt},
+		{Name: "feed_name", Value: post.FeedName},
+		{Name: "starred_at", Value: post.StarredAt},
+	}
+}
+
+func searchResultRecord(post database.SearchPostsRow) output.Record {
+	return output.Record{
+		{Name: "id", Value: post.ID},
+		{Name: "title", Value: post.Title},
+		{Name: "url", Value: post.Url},
+		{Name: "published_at", Value: post.PublishedAt},
+		{Name: "feed_name", Value: post.FeedName},
+		{Name: "rank", Value: post.Rank},
+		{Name: "snippet", Value: post.Snippet},
+	}
+}
+
+func healthRecord(feed database.GetFeedHealthRow, status string) output.Record {
+	return output.Record{
+		{Name: "name", Value: feed.Name},
+		{Name: "url", Value: feed.Url},
+		{Name: "status", Value: status},
+		{Name: "consecutive_failures", Value: feed.ConsecutiveFailures},
+		{Name: "disabled_at", Value: feed.DisabledAt},
+		{Name: "last_fetched_at", Value: feed.LastFetchedAt},
+		{Name: "last_attempt_at", Value: feed.LastAttemptAt},
+		{Name: "last_status_code", Value: feed.LastStatusCode},
+		{Name: "last_error", Value: feed.LastError},
+		{Name: "avg_duration_ms", Value: feed.AvgDurationMs},
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/chandanbsd/gator/internal/database"
+	"github.com/chandanbsd/gator/internal/output"
+	"github.com/chandanbsd/gator/internal/render"
+	"github.com/chandanbsd/gator/internal/search"
+)
+
+const defaultSearchLimit = 10
+
+func searchHandler(s *state, cmd command, user database.User) error {
+	query, err := search.ToTSQuery(strings.Join(cmd.Arguments, " "))
+	if err != nil {
+		return invalidArgs("%v", err)
+	}
+
+	limit := cmd.intFlag("limit")
+	if limit < 1 {
+		return invalidArgs("limit must be positive")
+	}
+
+	posts, err := s.db.SearchPosts(s.ctx, database.SearchPostsParams{
+		Query:    query,
+		AllFeeds: cmd.boolFlag("all-feeds"),
+		UserID:   user.ID,
+		Limit:    int32(limit),
+	})
+	if err != nil {
+		return dbError(err, "searching posts")
+	}
+
+	if cmd.Output != output.Table {
+		records := make([]output.Record, 0, len(posts))
+		for _, post := range posts {
+			records = append(records, searchResultRecord(post))
+		}
+		return printRecords(cmd, searchResultRecord(database.SearchPostsRow{}), records)
+	}
+
+	if len(posts) == 0 {
+		fmt.Println("No posts found")
+		return nil
+	}
+
+	for _, post := range posts {
+		published := post.PublishedAt.Local().Format(time.DateOnly)
+
+		fmt.Printf("%s  %s  %s\n", shortID(post.ID), published, post.FeedName)
+		fmt.Printf("  %s\n  %s\n", post.Title, post.Url)
+
+		snippet := strings.Join(strings.Fields(render.HTMLToText(post.Snippet)), " ")
+		if snippet != "" {
+			fmt.Printf("  %s\n", snippet)
+		}
+		fmt.Println()
+	}
+	return nil
+}
@@ -0,0 +1,424 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/chandanbsd/gator/internal/database"
+	"github.com/chandanbsd/gator/internal/output"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultServeAddr     = "localhost:8080"
+	defaultServeInterval = 10 * time.Minute
+	defaultAPIPostLimit  = 20
+	maxAPIPostLimit      = 500
+	// maxRequestBody bounds the JSON bodies the API reads.
+	maxRequestBody = 1 << 20
+	// userHeader names the user an API request acts for. Without it the
+	// request acts for the current user of the config file.
+	userHeader = "X-Gator-User"
+)
+
+func serveHandler(s *state, cmd command) error {
+	opts, err := parseFetchFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	addr := cmd.stringFlag("addr")
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listening on %s: %w", addr, err)
+	}
+
+	api := &apiServer{s: s}
+	server := &http.Server{
+		Handler:           logRequests(api.routes()),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	defer cancel()
+
+	// The aggregator shares the process so the API serves fresh posts
+	// without a separate gator agg.
+	var wg sync.WaitGroup
+	if interval := cmd.durationFlag("interval"); interval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			newAggregator(s, interval, opts).runAndReport(ctx)
+		}()
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(listener)
+	}()
+	fmt.Printf("Serving the API on http://%s\n", listener.Addr())
+
+	select {
+	case err = <-serveErr:
+		err = fmt.Errorf("serving the API: %w", err)
+	case <-ctx.Done():
+		shutdownCtx, stop := context.WithTimeout(context.Background(), shutdownGrace)
+		defer stop()
+		err = server.Shutdown(shutdownCtx)
+	}
+
+	cancel()
+	wg.Wait()
+	return err
+}
+
+// apiServer serves the JSON API of gator serve. Its responses use the
+// records of the --output option, so scripts see the same fields whether
+// they run gator or call the API.
+type apiServer struct {
+	s *state
+}
+
+// apiHandler handles a request for user. A returned error is sent as a JSON
+// error with the status its kind maps to, see httpStatus.
+type apiHandler func(w http.ResponseWriter, r *http.Request, user database.User) error
+
+func (api *apiServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /feeds", api.handle(api.listFeeds))
+	mux.Handle("POST /feeds", api.handle(api.createFeed))
+	mux.Handle("GET /follows", api.handle(api.listFollows))
+	mux.Handle("POST /follows", api.handle(api.follow))
+	mux.Handle("DELETE /follows", api.handle(api.unfollow))
+	mux.Handle("GET /posts", api.handle(api.listPosts))
+	mux.Handle("PUT /posts/{id}/read", api.handle(api.markRead))
+	mux.Handle("DELETE /posts/{id}/read", api.handle(api.markUnread))
+	return mux
+}
+
+// handle looks up the user of the request, runs h and reports its error.
+func (api *apiServer) handle(h apiHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.Header.Get(userHeader)
+		if name == "" {
+			name = api.s.conf.CurrentUserName
+		}
+
+		user, err := api.s.db.GetUser(r.Context(), name)
+		if err != nil {
+			err = dbError(err, "user %q", name)
+		} else {
+			err = h(w, r, user)
+		}
+		if err != nil {
+			writeError(w, err)
+		}
+	})
+}
+
+func (api *apiServer) listFeeds(w http.ResponseWriter, r *http.Request, user database.User) error {
+	feeds, err := api.s.db.GetFeeds(r.Context())
+	if err != nil {
+		return dbError(err, "listing feeds")
+	}
+
+	records := make([]output.Record, 0, len(feeds))
+	for _, feed := range feeds {
+		records = append(records, feedRecord(feed))
+	}
+	return writeJSON(w, http.StatusOK, map[string]any{"feeds": records})
+}
+
+func (api *apiServer) createFeed(w http.ResponseWriter, r *http.Request, user database.User) error {
+	var body struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+		// Follow defaults to true, like gator addfeed.
+		Follow *bool `json:"follow"`
+	}
+	err := readJSON(r, &body)
+	if err != nil {
+		return err
+	}
+	if body.Name == "" || body.URL == "" {
+		return invalidArgs("name and url are required")
+	}
+
+	// The feed and its follow go in together, so a failed follow does not
+	// leave a feed nobody follows.
+	tx, err := api.s.conn.BeginTx(r.Context(), nil)
+	if err != nil {
+		return dbError(err, "creating feed %s", body.URL)
+	}
+	defer tx.Rollback()
+	qtx := api.s.db.WithTx(tx)
+
+	now := sql.NullTime{Time: time.Now(), Valid: true}
+	feed, err := qtx.CreateFeed(r.Context(), database.CreateFeedParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		Name:      body.Name,
+		Url:       body.URL,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		return dbError(err, "creating feed %s", body.URL)
+	}
+
+	if body.Follow == nil || *body.Follow {
+		_, err = qtx.CreateFeedFollow(r.Context(), database.CreateFeedFollowParams{
+			ID:        uuid.New(),
+			CreatedAt: now,
+			FeedID:    feed.ID,
+			UserID:    user.ID,
+		})
+		if err != nil {
+			return dbError(err, "following feed %s", body.URL)
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return dbError(err, "creating feed %s", body.URL)
+	}
+
+	return writeJSON(w, http.StatusCreated, feedRecord(database.GetFeedsRow{
+		Name:     feed.Name,
+		Url:      feed.Url,
+		UserName: user.Name,
+	}))
+}
+
+func (api *apiServer) listFollows(w http.ResponseWriter, r *http.Request, user database.User) error {
+	follows, err := api.s.db.GetFeedFollowsForUser(r.Context(), user.ID)
+	if err != nil {
+		return dbError(err, "getting feeds followed by %s", user.Name)
+	}
+
+	records := make([]output.Record, 0, len(follows))
+	for _, follow := range follows {
+		records = append(records, feedFollowRecord(follow))
+	}
+	return writeJSON(w, http.StatusOK, map[string]any{"follows": records})
+}
+
+func (api *apiServer) follow(w http.ResponseWriter, r *http.Request, user database.User) error {
+	var body struct {
+		URL string `json:"url"`
+	}
+	err := readJSON(r, &body)
+	if err != nil {
+		return err
+	}
+	if body.URL == "" {
+		return invalidArgs("url is required")
+	}
+
+	feed, err := api.s.db.GetFeedByUrl(r.Context(), body.URL)
+	if err != nil {
+		return dbError(err, "feed %s", body.URL)
+	}
+
+	follow, err := api.s.db.CreateFeedFollow(r.Context(), database.CreateFeedFollowParams{
+		ID:        uuid.New(),
+		CreatedAt: sql.NullTime{Time: time.Now(), Valid: true},
+		FeedID:    feed.ID,
+		UserID:    user.ID,
+	})
+	if err != nil {
+		return dbError(err, "following feed %s", body.URL)
+	}
+
+	return writeJSON(w, http.StatusCreated, feedFollowRecord(database.GetFeedFollowsForUserRow{
+		FeedName:  follow.FeedName,
+		FeedUrl:   body.URL,
+		Folder:    follow.Folder,
+		CreatedAt: follow.CreatedAt,
+	}))
+}
+
+func (api *apiServer) unfollow(w http.ResponseWriter, r *http.Request, user database.User) error {
+	feedURL := r.URL.Query().Get("url")
+	if feedURL == "" {
+		return invalidArgs("the url query parameter is required")
+	}
+
+	count, err := api.s.db.DeleteFeedFollow(r.Context(), database.DeleteFeedFollowParams{
+		UserID: user.ID,
+		Url:    feedURL,
+	})
+	if err != nil {
+		return dbError(err, "unfollowing feed %s", feedURL)
+	}
+	if count == 0 {
+		return fmt.Errorf("feed %s in the feeds you follow: %w", feedURL, errNotFound)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// listPosts takes the filters of gator browse as query parameters: limit,
+// feed, since, all and after. The response holds the cursor of the next
+// page when the page is full.
+func (api *apiServer) listPosts(w http.ResponseWriter, r *http.Request, user database.User) error {
+	query := r.URL.Query()
+
+	params := database.GetPostsForUserParams{
+		UserID: user.ID,
+		Limit:  defaultAPIPostLimit,
+	}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxAPIPostLimit {
+			return invalidArgs("limit must be a number from 1 to %d", maxAPIPostLimit)
+		}
+		params.Limit = int32(n)
+	}
+	if feedURL := query.Get("feed"); feedURL != "" {
+		params.FeedUrl = sql.NullString{String: feedURL, Valid: true}
+	}
+	if since := query.Get("since"); since != "" {
+		d, err := time.ParseDuration(since)
+		if err != nil {
+			return invalidArgs("since must be a duration, e.g. 24h")
+		}
+		params.Since = sql.NullTime{Time: time.Now().Add(-d), Valid: true}
+	}
+	if all := query.Get("all"); all != "" {
+		includeRead, err := strconv.ParseBool(all)
+		if err != nil {
+			return invalidArgs("all must be true or false")
+		}
+		params.IncludeRead = includeRead
+	}
+	if after := query.Get("after"); after != "" {
+		cursor, err := parsePostCursor(after)
+		if err != nil {
+			return invalidArgs("%v", err)
+		}
+		params.AfterPublishedAt = sql.NullTime{Time: cursor.PublishedAt, Valid: true}
+		params.AfterID = uuid.NullUUID{UUID: cursor.ID, Valid: true}
+	}
+
+	posts, err := api.s.db.GetPostsForUser(r.Context(), params)
+	if err != nil {
+		return dbError(err, "getting posts for %s", user.Name)
+	}
+
+	records := make([]output.Record, 0, len(posts))
+	for _, post := range posts {
+		records = append(records, postRecord(post))
+	}
+	response := map[string]any{"posts": records, "next": nil}
+	if len(posts) == int(params.Limit) {
+		response["next"] = cursorOf(posts[len(posts)-1]).String()
+	}
+	return writeJSON(w, http.StatusOK, response)
+}
+
+func (api *apiServer) markRead(w http.ResponseWriter, r *http.Request, user database.User) error {
+	postID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		return invalidArgs("%q is not a post id", r.PathValue("id"))
+	}
+
+	err = checkPostFollowed(r.Context(), api.s, user, postID)
+	if err != nil {
+		return err
+	}
+
+	_, err = api.s.db.MarkPostRead(r.Context(), database.MarkPostReadParams{
+		UserID: user.ID,
+		PostID: postID,
+		ReadAt: time.Now(),
+	})
+	if err != nil {
+		return dbError(err, "marking post %s read", postID)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (api *apiServer) markUnread(w http.ResponseWriter, r *http.Request, user database.User) error {
+	postID, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		return invalidArgs("%q is not a post id", r.PathValue("id"))
+	}
+
+	err = checkPostFollowed(r.Context(), api.s, user, postID)
+	if err != nil {
+		return err
+	}
+
+	_, err = api.s.db.MarkPostUnread(r.Context(), database.MarkPostUnreadParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		return dbError(err, "marking post %s unread", postID)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// readJSON decodes the JSON body of r into v, rejecting unknown fields so
+// typos do not go unnoticed.
+func readJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxRequestBody))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err != nil {
+		return invalidArgs("reading the request body: %v", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(v)
+}
+
+// writeError sends err as {"error": message}. Messages of server errors
+// stay in the log, they are of no use to the client.
+func writeError(w http.ResponseWriter, err error) {
+	status := httpStatus(err)
+	message := err.Error()
+	if status == http.StatusInternalServerError {
+		fmt.Printf("Error: %v\n", err)
+		message = http.StatusText(status)
+	}
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// statusRecorder remembers the status a handler wrote, for logRequests.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *statusRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// logRequests prints a line per request with its status and duration.
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		fmt.Printf("%s %s %d %v\n", r.Method, r.URL.RequestURI(), rec.status, time.Since(start).Round(time.Millisecond))
+	})
+}
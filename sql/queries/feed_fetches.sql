@@ -0,0 +1,53 @@
+-- name: CreateFeedFetch :exec
+INSERT INTO feed_fetches(
+	id,
+	feed_id,
+	started_at,
+	duration_ms,
+	status_code,
+	bytes,
+	items_parsed,
+	items_inserted,
+	error)
+VALUES (
+	$1,
+	$2,
+	$3,
+	$4,
+	$5,
+	$6,
+	$7,
+	$8,
+	$9
+);
+
+-- name: PruneFeedFetches :exec
+DELETE FROM feed_fetches
+WHERE feed_id = $1
+	AND started_at < $2;
+
+-- name: GetFeedHealth :many
+SELECT f.name, f.url, f.consecutive_failures, f.disabled_at, f.last_fetched_at,
+	last.started_at AS last_attempt_at,
+	last.status_code AS last_status_code,
+	last.error AS last_error,
+	coalesce(recent.avg_duration_ms, 0)::integer AS avg_duration_ms
+FROM feeds f
+LEFT JOIN LATERAL (
+	SELECT ff.started_at, ff.status_code, ff.error
+	FROM feed_fetches ff
+	WHERE ff.feed_id = f.id
+	ORDER BY ff.started_at DESC
+	LIMIT 1
+) last ON true
+LEFT JOIN LATERAL (
+	SELECT avg(ff.duration_ms) AS avg_duration_ms
+	FROM (
+		SELECT duration_ms
+		FROM feed_fetches
+		WHERE feed_id = f.id
+		ORDER BY started_at DESC
+		LIMIT sqlc.arg('recent_fetches')
+	) ff
+) recent ON true
+ORDER BY f.disabled_at IS NULL, f.consecutive_failures DESC, f.name;
@@ -6,14 +6,16 @@ WITH inserted_feed_follow AS (
     created_at,
     updated_at,
     feed_id,
-    user_id
+    user_id,
+    folder
   )
   VALUES (
     $1,
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $6
   )
   RETURNING *
 )
@@ -25,16 +27,31 @@ INNER JOIN feeds on inserted_feed_follow.feed_id = feeds.id
 INNER JOIN users on inserted_feed_follow.user_id = users.id;
 
 -- name: GetFeedFollowsForUser :many
-SELECT feeds.name as feed_name
+SELECT feeds.name as feed_name, feeds.url as feed_url, feed_follows.folder, feed_follows.created_at
 FROM feed_follows
 INNER JOIN feeds on feeds.id = feed_follows.feed_id
 INNER JOIN users on users.id = feed_follows.user_id
 WHERE feed_follows.user_id = $1;
 
 
--- name: DeleteFeedFollow :exec
+-- name: GetFeedFollowsForExport :many
+SELECT feeds.name, feeds.url, feeds.site_url, feed_follows.folder
+FROM feed_follows
+INNER JOIN feeds on feeds.id = feed_follows.feed_id
+WHERE feed_follows.user_id = $1
+ORDER BY feed_follows.folder NULLS FIRST, feeds.name;
+
+-- name: DeleteFeedFollow :execrows
 DELETE
 FROM feed_follows
 USING feeds
 WHERE feed_follows.feed_id = feeds.id
   AND feed_follows.user_id = $1 and feeds.url = $2;
+
+-- name: IsFollowingFeed :one
+SELECT EXISTS (
+  SELECT 1
+  FROM feed_follows
+  WHERE user_id = $1
+    AND feed_id = $2
+);
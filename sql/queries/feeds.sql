@@ -5,14 +5,16 @@ INSERT INTO feeds(
 	updated_at,
 	name,
 	url,
-	user_id)
+	user_id,
+	site_url)
 VALUES (
 	$1,
 	$2,
 	$3,
 	$4,
 	$5,
-	$6
+	$6,
+	$7
 )
 RETURNING *;
 
@@ -28,10 +30,52 @@ where f.url = $1;
 
 -- name: MarkFeedFetched :exec
 update feeds
-set last_fetched_at = $1
-where ID = $2;
+set last_fetched_at = $1,
+	etag = $2,
+	last_modified = $3,
+	next_fetch_at = $4,
+	fetch_interval_seconds = $5,
+	site_url = $6,
+	consecutive_failures = 0
+where ID = $7;
 
--- name: GetNextFeedToFetch :one
-select *
-from feeds
-order by last_fetched_at desc;
+-- name: GetNextFeedsToFetch :many
+update feeds
+set next_fetch_at = sqlc.arg(lease_until)
+where ID in (
+	select ID
+	from feeds
+	where disabled_at is null
+		and (next_fetch_at is null
+			or next_fetch_at <= sqlc.arg(due_at))
+	order by next_fetch_at asc nulls first
+	limit sqlc.arg(max_feeds)
+	for update skip locked
+)
+returning *;
+
+-- name: RecordFeedFailure :exec
+update feeds
+set consecutive_failures = consecutive_failures + 1,
+	next_fetch_at = $1,
+	disabled_at = $2
+where ID = $3;
+
+-- name: ReleaseFeed :exec
+update feeds
+set next_fetch_at = sqlc.arg(due_at)
+where ID = sqlc.arg(id)
+	and next_fetch_at = sqlc.arg(lease_until);
+
+-- name: EnableFeed :execrows
+update feeds
+set disabled_at = null,
+	consecutive_failures = 0,
+	next_fetch_at = null
+where url = $1;
+
+-- name: DisableFeed :execrows
+update feeds
+set disabled_at = $1
+where url = $2
+	and disabled_at is null;
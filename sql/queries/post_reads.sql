@@ -0,0 +1,48 @@
+-- name: MarkPostRead :execrows
+INSERT INTO post_reads(user_id, post_id, read_at)
+VALUES (
+	$1,
+	$2,
+	$3
+)
+ON CONFLICT DO NOTHING;
+
+-- name: MarkPostUnread :execrows
+DELETE
+FROM post_reads
+WHERE user_id = $1
+	AND post_id = $2;
+
+-- name: MarkPostsRead :execrows
+INSERT INTO post_reads(user_id, post_id, read_at)
+SELECT sqlc.arg('user_id')::uuid, p.id, sqlc.arg('read_at')::timestamp
+FROM posts p
+INNER JOIN feeds f ON f.id = p.feed_id
+WHERE EXISTS (
+	SELECT 1
+	FROM feed_follows ff
+	WHERE ff.feed_id = p.feed_id
+		AND ff.user_id = sqlc.arg('user_id')::uuid
+)
+	AND (sqlc.narg('feed_url')::text IS NULL OR f.url = sqlc.narg('feed_url'))
+	AND (sqlc.narg('before')::timestamptz IS NULL OR p.published_at < sqlc.narg('before'))
+ON CONFLICT DO NOTHING;
+
+-- name: MarkPostsUnread :execrows
+DELETE
+FROM post_reads pr
+USING posts p, feeds f
+WHERE pr.post_id = p.id
+	AND f.id = p.feed_id
+	AND pr.user_id = sqlc.arg('user_id')
+	AND (sqlc.narg('feed_url')::text IS NULL OR f.url = sqlc.narg('feed_url'))
+	AND (sqlc.narg('before')::timestamptz IS NULL OR p.published_at < sqlc.narg('before'));
+
+-- name: IsPostFollowed :one
+SELECT EXISTS (
+	SELECT 1
+	FROM posts p
+	INNER JOIN feed_follows ff ON ff.feed_id = p.feed_id
+	WHERE p.id = $1
+		AND ff.user_id = $2
+);
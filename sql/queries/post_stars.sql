@@ -0,0 +1,22 @@
+-- name: StarPost :execrows
+INSERT INTO post_stars(user_id, post_id, starred_at)
+VALUES (
+	$1,
+	$2,
+	$3
+)
+ON CONFLICT DO NOTHING;
+
+-- name: UnstarPost :execrows
+DELETE
+FROM post_stars
+WHERE user_id = $1
+	AND post_id = $2;
+
+-- name: GetStarredPostsForUser :many
+SELECT p.id, p.title, p.url, p.published_at, f.name AS feed_name, ps.starred_at
+FROM post_stars ps
+INNER JOIN posts p ON p.id = ps.post_id
+INNER JOIN feeds f ON f.id = p.feed_id
+WHERE ps.user_id = $1
+ORDER BY ps.starred_at desc;
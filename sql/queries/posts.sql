@@ -1,33 +1,175 @@
--- name: CreatePost :exec
-INSERT INTO posts(
-    id,
-	created_at,
-	updated_at,
-	title,
-	url,
-	description,
-	published_at,
-	feed_id)
-VALUES
-(
-    $1,
-    $2,
-    $3,
-    $4,
-    $5,
-    $6,
-    $7,
-    $8
-);
+-- name: AdoptPostGUIDs :exec
+UPDATE posts p
+SET guid = a.guid,
+    dedup_key = a.dedup_key
+FROM unnest(
+    sqlc.arg('guids')::text[],
+    sqlc.arg('dedup_keys')::text[],
+    sqlc.arg('urls')::text[]
+) AS a(guid, dedup_key, url)
+WHERE p.feed_id = sqlc.arg('feed_id')
+    AND p.guid IS NULL
+    AND p.dedup_key = 'url:' || a.url
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts g
+        WHERE g.feed_id = sqlc.arg('feed_id')
+            AND g.dedup_key = a.dedup_key
+    );
+
+-- name: GetNewerPostsForUser :many
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id,
+    f.name AS feed_name,
+    EXISTS (
+        SELECT 1
+        FROM post_reads pr
+        WHERE pr.post_id = p.id
+            AND pr.user_id = sqlc.arg('user_id')
+    ) AS read
+FROM posts p
+INNER JOIN feeds f ON f.id = p.feed_id
+WHERE EXISTS (
+    SELECT 1
+    FROM feed_follows ff
+    WHERE ff.feed_id = p.feed_id
+        AND ff.user_id = sqlc.arg('user_id')
+)
+    AND (sqlc.narg('feed_url')::text IS NULL OR f.url = sqlc.narg('feed_url'))
+    AND (sqlc.narg('since')::timestamptz IS NULL OR p.published_at >= sqlc.narg('since'))
+    AND (sqlc.arg('include_read')::boolean OR NOT EXISTS (
+        SELECT 1
+        FROM post_reads pr
+        WHERE pr.post_id = p.id
+            AND pr.user_id = sqlc.arg('user_id')
+    ))
+    AND (p.published_at, p.id) > (sqlc.arg('before_published_at')::timestamptz, sqlc.arg('before_id')::uuid)
+ORDER BY p.published_at, p.id
+LIMIT sqlc.arg('limit');
 
 -- name: GetPostsForUser :many
-SELECT *
+SELECT p.id, p.created_at, p.updated_at, p.title, p.url, p.description, p.published_at, p.feed_id,
+    f.name AS feed_name,
+    EXISTS (
+        SELECT 1
+        FROM post_reads pr
+        WHERE pr.post_id = p.id
+            AND pr.user_id = sqlc.arg('user_id')
+    ) AS read
 FROM posts p
+INNER JOIN feeds f ON f.id = p.feed_id
 WHERE EXISTS (
     SELECT 1
     FROM feed_follows ff
     WHERE ff.feed_id = p.feed_id
-        AND ff.user_id = $1
+        AND ff.user_id = sqlc.arg('user_id')
 )
-ORDER BY published_at desc
-LIMIT $2;
+    AND (sqlc.narg('feed_url')::text IS NULL OR f.url = sqlc.narg('feed_url'))
+    AND (sqlc.narg('since')::timestamptz IS NULL OR p.published_at >= sqlc.narg('since'))
+    AND (sqlc.arg('include_read')::boolean OR NOT EXISTS (
+        SELECT 1
+        FROM post_reads pr
+        WHERE pr.post_id = p.id
+            AND pr.user_id = sqlc.arg('user_id')
+    ))
+    AND (sqlc.narg('after_published_at')::timestamptz IS NULL
+        OR (p.published_at, p.id) < (sqlc.narg('after_published_at'), sqlc.narg('after_id')::uuid))
+ORDER BY p.published_at DESC, p.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetPostIDsByPrefix :many
+SELECT p.id
+FROM posts p
+WHERE p.id::text LIKE sqlc.arg('prefix')::text || '%'
+    AND (
+        EXISTS (
+            SELECT 1
+            FROM feed_follows ff
+            WHERE ff.feed_id = p.feed_id
+                AND ff.user_id = sqlc.arg('user_id')
+        )
+        OR EXISTS (
+            SELECT 1
+            FROM post_stars ps
+            WHERE ps.post_id = p.id
+                AND ps.user_id = sqlc.arg('user_id')
+        )
+    )
+ORDER BY p.id
+LIMIT 2;
+
+-- name: SearchPosts :many
+SELECT p.id, p.title, p.url, p.published_at, f.name AS feed_name,
+    ts_rank(p.search, q.query) AS rank,
+    ts_headline(
+        'english',
+        coalesce(p.description, p.title),
+        q.query,
+        'StartSel=«, StopSel=», MaxWords=30, MinWords=12, MaxFragments=2'
+    )::text AS snippet
+FROM posts p
+INNER JOIN feeds f ON f.id = p.feed_id
+CROSS JOIN (SELECT to_tsquery('english', sqlc.arg('query')::text) AS query) q
+WHERE p.search @@ q.query
+    AND (sqlc.arg('all_feeds')::boolean OR EXISTS (
+        SELECT 1
+        FROM feed_follows ff
+        WHERE ff.feed_id = p.feed_id
+            AND ff.user_id = sqlc.arg('user_id')
+    ))
+ORDER BY rank desc, p.published_at desc
+LIMIT sqlc.arg('limit');
+
+-- name: UpsertPosts :many
+INSERT INTO posts(
+    id,
+    created_at,
+    updated_at,
+    title,
+    url,
+    description,
+    published_at,
+    feed_id,
+    guid,
+    dedup_key,
+    content_hash,
+    duplicate_of)
+SELECT
+    i.id,
+    sqlc.arg('created_at')::timestamp,
+    NULL,
+    i.title,
+    i.url,
+    NULLIF(i.description, ''),
+    i.published_at,
+    sqlc.arg('feed_id')::uuid,
+    NULLIF(i.guid, ''),
+    i.dedup_key,
+    i.content_hash,
+    (
+        SELECT d.id
+        FROM posts d
+        WHERE d.url = i.url
+            AND d.url <> ''
+            AND d.feed_id <> sqlc.arg('feed_id')
+            AND d.duplicate_of IS NULL
+        ORDER BY d.created_at
+        LIMIT 1
+    )
+FROM unnest(
+    sqlc.arg('ids')::uuid[],
+    sqlc.arg('titles')::text[],
+    sqlc.arg('urls')::text[],
+    sqlc.arg('descriptions')::text[],
+    sqlc.arg('published_ats')::timestamptz[],
+    sqlc.arg('guids')::text[],
+    sqlc.arg('dedup_keys')::text[],
+    sqlc.arg('content_hashes')::text[]
+) AS i(id, title, url, description, published_at, guid, dedup_key, content_hash)
+ON CONFLICT (feed_id, dedup_key) DO UPDATE
+SET title = excluded.title,
+    url = excluded.url,
+    description = excluded.description,
+    content_hash = excluded.content_hash,
+    updated_at = CASE WHEN posts.content_hash = '' THEN posts.updated_at ELSE excluded.created_at END
+WHERE posts.content_hash <> excluded.content_hash
+RETURNING id, (xmax = 0)::boolean AS inserted, (updated_at IS NOT DISTINCT FROM sqlc.arg('created_at')::timestamp)::boolean AS changed;
@@ -0,0 +1,12 @@
+-- +goose Up
+-- post_id deliberately does not cascade: deleting a starred post fails, so
+-- pruning old posts has to leave starred ones alone.
+CREATE TABLE post_stars(
+	user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	post_id UUID NOT NULL REFERENCES posts (id),
+	starred_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (user_id, post_id)
+);
+
+-- +goose Down
+DROP TABLE post_stars;
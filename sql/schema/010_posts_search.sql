@@ -0,0 +1,14 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN search tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+	setweight(to_tsvector('english', coalesce(description, '')), 'B')
+) STORED;
+
+CREATE INDEX posts_search_idx ON posts USING GIN (search);
+
+-- +goose Down
+DROP INDEX posts_search_idx;
+
+ALTER TABLE posts
+DROP COLUMN search;
@@ -0,0 +1,15 @@
+-- +goose Up
+UPDATE posts
+SET published_at = created_at
+WHERE published_at IS NULL;
+
+ALTER TABLE posts
+ALTER COLUMN published_at SET NOT NULL;
+
+CREATE INDEX posts_published_at_id_idx ON posts (published_at DESC, id DESC);
+
+-- +goose Down
+DROP INDEX posts_published_at_id_idx;
+
+ALTER TABLE posts
+ALTER COLUMN published_at DROP NOT NULL;
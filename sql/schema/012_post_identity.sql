@@ -0,0 +1,72 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN guid TEXT NULL,
+ADD COLUMN dedup_key TEXT NULL,
+ADD COLUMN content_hash TEXT NOT NULL DEFAULT '',
+ADD COLUMN duplicate_of UUID NULL REFERENCES posts (id) ON DELETE SET NULL;
+
+-- Existing posts were identified by their url. Their hash is computed the
+-- way feed.ContentHash does, from the title and description as stored, so
+-- the next fetch does not see them all as changed. Early versions stored
+-- no description at all, so a post without one gets an empty hash instead:
+-- its content is refreshed on the next fetch without counting as updated.
+UPDATE posts
+SET dedup_key = 'url:' || url,
+    content_hash = CASE
+        WHEN description IS NULL THEN ''
+        ELSE encode(sha256(convert_to(title || E'\n' || description, 'UTF8')), 'hex')
+    END;
+
+ALTER TABLE posts
+ALTER COLUMN dedup_key SET NOT NULL,
+ALTER COLUMN content_hash DROP DEFAULT;
+
+ALTER TABLE posts
+DROP CONSTRAINT unique_posts_url;
+
+ALTER TABLE posts
+ADD CONSTRAINT unique_posts_feed_dedup_key unique (feed_id, dedup_key);
+
+CREATE INDEX posts_url_idx ON posts (url);
+
+-- +goose Down
+DROP INDEX posts_url_idx;
+
+ALTER TABLE posts
+DROP CONSTRAINT unique_posts_feed_dedup_key;
+
+-- Only one post per url can be kept, the oldest one. Stars do not cascade,
+-- so the stars of the others move to it first.
+INSERT INTO post_stars (user_id, post_id, starred_at)
+SELECT ps.user_id, o.id, ps.starred_at
+FROM post_stars ps
+INNER JOIN posts p ON p.id = ps.post_id
+INNER JOIN posts o ON o.url = p.url
+WHERE (p.created_at, p.id) > (o.created_at, o.id)
+    AND NOT EXISTS (
+        SELECT 1
+        FROM posts x
+        WHERE x.url = o.url
+            AND (x.created_at, x.id) < (o.created_at, o.id)
+    )
+ON CONFLICT DO NOTHING;
+
+DELETE FROM post_stars ps
+USING posts p, posts o
+WHERE ps.post_id = p.id
+    AND p.url = o.url
+    AND (p.created_at, p.id) > (o.created_at, o.id);
+
+DELETE FROM posts p
+USING posts o
+WHERE p.url = o.url
+    AND (p.created_at, p.id) > (o.created_at, o.id);
+
+ALTER TABLE posts
+ADD CONSTRAINT unique_posts_url unique (url);
+
+ALTER TABLE posts
+DROP COLUMN duplicate_of,
+DROP COLUMN content_hash,
+DROP COLUMN dedup_key,
+DROP COLUMN guid;
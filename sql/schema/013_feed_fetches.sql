@@ -0,0 +1,23 @@
+-- +goose Up
+CREATE TABLE feed_fetches(
+	id UUID PRIMARY KEY,
+	feed_id UUID NOT NULL REFERENCES feeds (id) ON DELETE CASCADE,
+	started_at TIMESTAMP NOT NULL,
+	duration_ms INTEGER NOT NULL,
+	status_code INTEGER NULL,
+	bytes BIGINT NULL,
+	items_parsed INTEGER NOT NULL,
+	items_inserted INTEGER NOT NULL,
+	error TEXT NULL
+);
+
+CREATE INDEX feed_fetches_feed_id_started_at_idx ON feed_fetches (feed_id, started_at DESC);
+
+ALTER TABLE feeds
+ADD COLUMN consecutive_failures INTEGER NOT NULL DEFAULT 0;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN consecutive_failures;
+
+DROP TABLE feed_fetches;
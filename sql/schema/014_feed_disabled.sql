@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN disabled_at TIMESTAMP NULL;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN disabled_at;
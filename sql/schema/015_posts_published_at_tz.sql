@@ -0,0 +1,9 @@
+-- +goose Up
+-- published_at held wall clocks in whatever offset the feed used, which
+-- TIMESTAMP drops. Existing values are taken as UTC, the best guess left.
+ALTER TABLE posts
+ALTER COLUMN published_at TYPE TIMESTAMPTZ USING published_at AT TIME ZONE 'UTC';
+
+-- +goose Down
+ALTER TABLE posts
+ALTER COLUMN published_at TYPE TIMESTAMP USING published_at AT TIME ZONE 'UTC';
@@ -0,0 +1,6 @@
+-- +goose Up
+-- Lets short post ids be looked up with id::text LIKE 'prefix%'.
+CREATE INDEX posts_id_text_idx ON posts ((id::text) text_pattern_ops);
+
+-- +goose Down
+DROP INDEX posts_id_text_idx;
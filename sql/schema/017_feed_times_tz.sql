@@ -0,0 +1,20 @@
+-- +goose Up
+-- These held gator's local wall clock, which TIMESTAMP reads back as UTC,
+-- so feed health was off by the UTC offset. Existing values are taken in
+-- the time zone of the session, the best guess left.
+ALTER TABLE feeds
+ALTER COLUMN last_fetched_at TYPE TIMESTAMPTZ,
+ALTER COLUMN next_fetch_at TYPE TIMESTAMPTZ,
+ALTER COLUMN disabled_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE feed_fetches
+ALTER COLUMN started_at TYPE TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE feed_fetches
+ALTER COLUMN started_at TYPE TIMESTAMP;
+
+ALTER TABLE feeds
+ALTER COLUMN last_fetched_at TYPE TIMESTAMP,
+ALTER COLUMN next_fetch_at TYPE TIMESTAMP,
+ALTER COLUMN disabled_at TYPE TIMESTAMP;
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/chandanbsd/gator/internal/database"
+	"github.com/chandanbsd/gator/internal/output"
+	"github.com/google/uuid"
+)
+
+// shortIDLength is how many characters of a post id are shown. Any prefix
+// of at least minPostIDPrefix characters is accepted back as long as it is
+// unambiguous.
+const (
+	shortIDLength   = 8
+	minPostIDPrefix = 4
+)
+
+func starHandler(s *state, cmd command, user database.User) error {
+	postID, err := resolvePostID(s, cmd.Arguments[0], user)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.db.StarPost(s.ctx, database.StarPostParams{
+		UserID:    user.ID,
+		PostID:    postID,
+		StarredAt: time.Now(),
+	})
+	if err != nil {
+		return dbError(err, "starring post %s", shortID(postID))
+	}
+
+	if count == 0 {
+		fmt.Printf("Post %s was already starred\n", shortID(postID))
+		return nil
+	}
+	fmt.Printf("Starred post %s\n", shortID(postID))
+	return nil
+}
+
+func unstarHandler(s *state, cmd command, user database.User) error {
+	postID, err := resolvePostID(s, cmd.Arguments[0], user)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.db.UnstarPost(s.ctx, database.UnstarPostParams{
+		UserID: user.ID,
+		PostID: postID,
+	})
+	if err != nil {
+		return dbError(err, "unstarring post %s", shortID(postID))
+	}
+
+	if count == 0 {
+		fmt.Printf("Post %s was not starred\n", shortID(postID))
+		return nil
+	}
+	fmt.Printf("Unstarred post %s\n", shortID(postID))
+	return nil
+}
+
+func starredHandler(s *state, cmd command, user database.User) error {
+	posts, err := s.db.GetStarredPostsForUser(s.ctx, user.ID)
+	if err != nil {
+		return dbError(err, "getting posts starred by %s", user.Name)
+	}
+
+	if cmd.Output != output.Table {
+		records := make([]output.Record, 0, len(posts))
+		for _, post := range posts {
+			records = append(records, starredPostRecord(post))
+		}
+		return printRecords(cmd, starredPostRecord(database.GetStarredPostsForUserRow{}), records)
+	}
+
+	if len(posts) == 0 {
+		fmt.Println("No starred posts")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, post := range posts {
+		published := post.PublishedAt.Local().Format(time.DateOnly)
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", shortID(post.ID), published, post.FeedName, post.Title, post.Url)
+	}
+	return tw.Flush()
+}
+
+// resolvePostID turns a full post id or an unambiguous prefix of one into
+// the post id. Prefixes only match posts of the feeds user follows and the
+// posts user starred.
+func resolvePostID(s *state, id string, user database.User) (uuid.UUID, error) {
+	postID, err := uuid.Parse(id)
+	if err == nil {
+		return postID, nil
+	}
+
+	prefix := strings.ToLower(id)
+	if len(prefix) < minPostIDPrefix || strings.Trim(prefix, "0123456789abcdef-") != "" {
+		return uuid.Nil, invalidArgs("%q is not a post id, give at least %d characters of one", id, minPostIDPrefix)
+	}
+
+	ids, err := s.db.GetPostIDsByPrefix(s.ctx, database.GetPostIDsByPrefixParams{
+		Prefix: prefix,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return uuid.Nil, dbError(err, "looking up post %s", id)
+	}
+
+	switch len(ids) {
+	case 0:
+		return uuid.Nil, fmt.Errorf("post %s: %w", id, errNotFound)
+	case 1:
+		return ids[0], nil
+	default:
+		return uuid.Nil, invalidArgs("post id %s is ambiguous, give more characters", id)
+	}
+}
+
+func shortID(id uuid.UUID) string {
+	return id.String()[:shortIDLength]
+}
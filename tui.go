@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"time"
+
+	"github.com/chandanbsd/gator/internal/database"
+	"github.com/chandanbsd/gator/internal/render"
+	"github.com/chandanbsd/gator/internal/tui"
+	"github.com/google/uuid"
+)
+
+// tuiPostLimit is how many posts the reader loads for a feed, newest first.
+const tuiPostLimit = 500
+
+func tuiHandler(s *state, cmd command, user database.User) error {
+	if !render.IsTerminal(os.Stdin) || !render.IsTerminal(os.Stdout) {
+		return invalidArgs("tui needs a terminal, use browse in scripts")
+	}
+	return tui.Run(s.ctx, os.Stdin, os.Stdout, "gator · "+user.Name, tuiSource{s: s, user: user})
+}
+
+// tuiSource gives the reader the feeds and posts of user.
+type tuiSource struct {
+	s    *state
+	user database.User
+}
+
+func (src tuiSource) Feeds(ctx context.Context) ([]tui.Feed, error) {
+	follows, err := src.s.db.GetFeedFollowsForUser(ctx, src.user.ID)
+	if err != nil {
+		return nil, dbError(err, "getting feeds followed by %s", src.user.Name)
+	}
+
+	feeds := make([]tui.Feed, 0, len(follows))
+	for _, follow := range follows {
+		feeds = append(feeds, tui.Feed{Name: follow.FeedName, URL: follow.FeedUrl})
+	}
+	return feeds, nil
+}
+
+func (src tuiSource) Posts(ctx context.Context, feedURL string) ([]tui.Post, error) {
+	rows, err := src.s.db.GetPostsForUser(ctx, database.GetPostsForUserParams{
+		UserID:      src.user.ID,
+		FeedUrl:     sql.NullString{String: feedURL, Valid: feedURL != ""},
+		IncludeRead: true,
+		Limit:       tuiPostLimit,
+	})
+	if err != nil {
+		return nil, dbError(err, "getting posts for %s", src.user.Name)
+	}
+
+	starred, err := src.s.db.GetStarredPostsForUser(ctx, src.user.ID)
+	if err != nil {
+		return nil, dbError(err, "getting posts starred by %s", src.user.Name)
+	}
+	isStarred := make(map[uuid.UUID]bool, len(starred))
+	for _, post := range starred {
+		isStarred[post.ID] = true
+	}
+
+	posts := make([]tui.Post, 0, len(rows))
+	for _, row := range rows {
+		posts = append(posts, tui.Post{
+			ID:          row.ID,
+			FeedName:    row.FeedName,
+			Title:       row.Title,
+			URL:         row.Url,
+			Description: row.Description.String,
+			Published:   row.PublishedAt,
+			Read:        row.Read,
+			Starred:     isStarred[row.ID],
+		})
+	}
+	return posts, nil
+}
+
+func (src tuiSource) SetRead(ctx context.Context, id uuid.UUID, read bool) error {
+	var err error
+	if read {
+		_, err = src.s.db.MarkPostRead(ctx, database.MarkPostReadParams{
+			UserID: src.user.ID,
+			PostID: id,
+			ReadAt: time.Now(),
+		})
+	} else {
+		_, err = src.s.db.MarkPostUnread(ctx, database.MarkPostUnreadParams{
+			UserID: src.user.ID,
+			PostID: id,
+		})
+	}
+	if err != nil {
+		return dbError(err, "marking post %s", shortID(id))
+	}
+	return nil
+}
+
+func (src tuiSource) SetStarred(ctx context.Context, id uuid.UUID, starred bool) error {
+	var err error
+	if starred {
+		_, err = src.s.db.StarPost(ctx, database.StarPostParams{
+			UserID:    src.user.ID,
+			PostID:    id,
+			StarredAt: time.Now(),
+		})
+	} else {
+		_, err = src.s.db.UnstarPost(ctx, database.UnstarPostParams{
+			UserID: src.user.ID,
+			PostID: id,
+		})
+	}
+	if err != nil {
+		return dbError(err, "starring post %s", shortID(id))
+	}
+	return nil
+}